@@ -0,0 +1,189 @@
+// Package accounts resolves named 3Play accounts, such as the sub-accounts of
+// different desks, to v2 and v3 clients configured with their credentials.
+//
+//	pool := accounts.NewAccountPool(accounts.NewFileProvider("accounts.json"),
+//		accounts.WithLimit(ratelimit.Limit{Rate: 5, Burst: 10}, ratelimit.Limit{Rate: 20, Burst: 20}),
+//		accounts.WithMetrics(collector))
+//	account, err := pool.Account(ctx, "sports")
+//	...
+//	file, err := account.V3.GetFileContext(ctx, id, v3api.CallParams{})
+package accounts
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nytimes/threeplay/metrics"
+	"github.com/nytimes/threeplay/ratelimit"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// Account is a named 3Play account with clients for both API versions
+type Account struct {
+	Name        string
+	Credentials Credentials
+	V2          *v2api.Client
+	V3          *v3api.Client
+}
+
+type limits struct {
+	api, static ratelimit.Limit
+}
+
+// AccountPool resolves account names to clients. Clients are built the first
+// time an account is resolved and rebuilt when its credentials change. The
+// accounts share the pool's HTTP client but have their own rate limits. It is
+// safe for concurrent use.
+type AccountPool struct {
+	provider      CredentialsProvider
+	httpClient    *http.Client
+	v2Options     []v2api.Option
+	v3Options     []v3api.Option
+	defaultLimits limits
+	limits        map[string]limits
+	collector     *metrics.Collector
+
+	mu       sync.Mutex
+	accounts map[string]*Account
+	limiters map[string]*ratelimit.Limiter
+}
+
+// PoolOption configures an AccountPool
+type PoolOption func(*AccountPool)
+
+// WithHTTPClient sets the HTTP client shared by the clients of the pool
+func WithHTTPClient(client *http.Client) PoolOption {
+	return func(p *AccountPool) {
+		p.httpClient = client
+	}
+}
+
+// WithV2Options adds options given to every v2 client of the pool
+func WithV2Options(opts ...v2api.Option) PoolOption {
+	return func(p *AccountPool) {
+		p.v2Options = append(p.v2Options, opts...)
+	}
+}
+
+// WithV3Options adds options given to every v3 client of the pool
+func WithV3Options(opts ...v3api.Option) PoolOption {
+	return func(p *AccountPool) {
+		p.v3Options = append(p.v3Options, opts...)
+	}
+}
+
+// WithLimit sets the rate limits of each account, for the API and for the
+// static host. The v2 and v3 clients of an account share them. Accounts are
+// not rate limited by default.
+func WithLimit(api, static ratelimit.Limit) PoolOption {
+	return func(p *AccountPool) {
+		p.defaultLimits = limits{api, static}
+	}
+}
+
+// WithAccountLimit overrides the rate limits of one account
+func WithAccountLimit(account string, api, static ratelimit.Limit) PoolOption {
+	return func(p *AccountPool) {
+		p.limits[account] = limits{api, static}
+	}
+}
+
+// WithMetrics attaches the clients of the pool to collector, with their
+// metrics labelled by account
+func WithMetrics(collector *metrics.Collector) PoolOption {
+	return func(p *AccountPool) {
+		p.collector = collector
+	}
+}
+
+// NewAccountPool returns a pool resolving accounts with provider
+func NewAccountPool(provider CredentialsProvider, opts ...PoolOption) *AccountPool {
+	p := &AccountPool{
+		provider:   provider,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		limits:     map[string]limits{},
+		accounts:   map[string]*Account{},
+		limiters:   map[string]*ratelimit.Limiter{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Account returns the named account with its current credentials
+func (p *AccountPool) Account(ctx context.Context, name string) (*Account, error) {
+	creds, err := p.provider.Credentials(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if account, ok := p.accounts[name]; ok && account.Credentials == creds {
+		return account, nil
+	}
+	account := p.newAccount(name, creds)
+	p.accounts[name] = account
+	return account, nil
+}
+
+// V2 returns the v2 client of the named account
+func (p *AccountPool) V2(ctx context.Context, name string) (*v2api.Client, error) {
+	account, err := p.Account(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return account.V2, nil
+}
+
+// V3 returns the v3 client of the named account
+func (p *AccountPool) V3(ctx context.Context, name string) (*v3api.Client, error) {
+	account, err := p.Account(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return account.V3, nil
+}
+
+// newAccount builds the clients of an account. It must be called with p.mu
+// held.
+func (p *AccountPool) newAccount(name string, creds Credentials) *Account {
+	v2Options := append([]v2api.Option{}, p.v2Options...)
+	v3Options := append([]v3api.Option{}, p.v3Options...)
+	if limiter := p.limiter(name); limiter != nil {
+		v2Options = append(v2Options, v2api.WithRateLimiter(limiter))
+		v3Options = append(v3Options, v3api.WithRateLimiter(limiter))
+	}
+	if p.collector != nil {
+		v2Options = append(v2Options, v2api.WithHooks(p.collector.V2AccountHooks(name)))
+		v3Options = append(v3Options, v3api.WithHooks(p.collector.V3AccountHooks(name)))
+	}
+	return &Account{
+		Name:        name,
+		Credentials: creds,
+		V2:          v2api.NewClientWithHTTPClient(creds.APIKey, creds.APISecret, p.httpClient, v2Options...),
+		V3:          v3api.NewClientWithHTTPClient(creds.APIKey, p.httpClient, v3Options...),
+	}
+}
+
+// limiter returns the rate limiter of an account, nil when its calls aren't
+// limited. It must be called with p.mu held.
+func (p *AccountPool) limiter(name string) *ratelimit.Limiter {
+	if limiter, ok := p.limiters[name]; ok {
+		return limiter
+	}
+	l, ok := p.limits[name]
+	if !ok {
+		l = p.defaultLimits
+	}
+	var limiter *ratelimit.Limiter
+	if l.api.Rate > 0 || l.static.Rate > 0 {
+		limiter = ratelimit.New(l.api, ratelimit.WithStaticLimit(l.static))
+	}
+	p.limiters[name] = limiter
+	return limiter
+}
@@ -0,0 +1,113 @@
+package accounts_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/accounts"
+	"github.com/nytimes/threeplay/metrics"
+	"github.com/nytimes/threeplay/ratelimit"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPool(server *threeplaytest.Server, provider accounts.CredentialsProvider, opts ...accounts.PoolOption) *accounts.AccountPool {
+	opts = append([]accounts.PoolOption{
+		accounts.WithV2Options(server.V2Options()...),
+		accounts.WithV3Options(server.V3Options()...),
+	}, opts...)
+	return accounts.NewAccountPool(provider, opts...)
+}
+
+func TestAccountPool(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	id := server.AddFile("video.mp4")
+
+	provider := accounts.StaticProvider{
+		"news":   {APIKey: server.APIKey, APISecret: threeplaytest.DefaultAPISecret},
+		"sports": {APIKey: "sports-key", APISecret: "sports-secret"},
+	}
+	pool := newPool(server, provider)
+
+	account, err := pool.Account(context.Background(), "news")
+	assert.Nil(err)
+	assert.Equal("news", account.Name)
+	_, err = account.V3.GetFile(strconv.Itoa(id), v3api.CallParams{})
+	assert.Nil(err)
+	_, err = account.V2.GetFile(uint(id))
+	assert.Nil(err)
+
+	v3, err := pool.V3(context.Background(), "sports")
+	assert.Nil(err)
+	_, err = v3.GetFile(strconv.Itoa(id), v3api.CallParams{})
+	assert.True(errors.Is(err, v3api.ErrUnauthorized))
+	v2, err := pool.V2(context.Background(), "sports")
+	assert.Nil(err)
+	_, err = v2.GetFile(uint(id))
+	assert.True(errors.Is(err, v2api.ErrUnauthorized))
+
+	_, err = pool.Account(context.Background(), "weather")
+	assert.NotNil(err)
+}
+
+func TestAccountPoolRotation(t *testing.T) {
+	assert := assert.New(t)
+	provider := accounts.StaticProvider{"news": {APIKey: "key-1"}}
+	pool := accounts.NewAccountPool(provider)
+
+	first, err := pool.Account(context.Background(), "news")
+	assert.Nil(err)
+	again, err := pool.Account(context.Background(), "news")
+	assert.Nil(err)
+	assert.True(first == again)
+
+	provider["news"] = accounts.Credentials{APIKey: "key-2"}
+	rotated, err := pool.Account(context.Background(), "news")
+	assert.Nil(err)
+	assert.False(first == rotated)
+	assert.Equal("key-2", rotated.Credentials.APIKey)
+}
+
+func TestAccountPoolLimitsAndMetrics(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	id := strconv.Itoa(server.AddFile("video.mp4"))
+
+	collector := metrics.NewCollector()
+	slow := ratelimit.Limit{Rate: 0.001, Burst: 1}
+	pool := newPool(server, accounts.StaticProvider{
+		"news":   {APIKey: server.APIKey},
+		"sports": {APIKey: server.APIKey},
+	}, accounts.WithAccountLimit("news", slow, slow), accounts.WithMetrics(collector))
+
+	news, err := pool.V3(context.Background(), "news")
+	assert.Nil(err)
+	_, err = news.GetFileContext(context.Background(), id, v3api.CallParams{})
+	assert.Nil(err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = news.GetFileContext(ctx, id, v3api.CallParams{})
+	assert.Equal(context.DeadlineExceeded, err)
+
+	// the limit of news doesn't apply to sports, even with the same key
+	sports, err := pool.V3(context.Background(), "sports")
+	assert.Nil(err)
+	for i := 0; i < 2; i++ {
+		_, err = sports.GetFileContext(ctx, id, v3api.CallParams{})
+		assert.Nil(err)
+	}
+
+	var b bytes.Buffer
+	assert.Nil(collector.Write(&b))
+	assert.Contains(b.String(), `threeplay_requests_total{api="v3",account="news",method="GET",endpoint="/v3/files/{id}",code="200"} 1`)
+	assert.Contains(b.String(), `threeplay_requests_total{api="v3",account="sports",method="GET",endpoint="/v3/files/{id}",code="200"} 2`)
+}
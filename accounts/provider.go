@@ -0,0 +1,147 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrUnknownAccount is returned when a provider has no credentials for an
+// account
+var ErrUnknownAccount = errors.New("unknown 3Play account")
+
+// Credentials authenticate the calls made on behalf of a 3Play account. The
+// secret is only needed by the v2 API.
+type Credentials struct {
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+}
+
+// CredentialsProvider returns the current credentials of named accounts.
+// Providers are asked on every resolution, so rotated credentials are picked
+// up without restarting.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context, account string) (Credentials, error)
+}
+
+// StaticProvider serves credentials from a fixed map of account names
+type StaticProvider map[string]Credentials
+
+// Credentials implements CredentialsProvider
+func (p StaticProvider) Credentials(ctx context.Context, account string) (Credentials, error) {
+	creds, ok := p[account]
+	if !ok {
+		return Credentials{}, fmt.Errorf("%w: %q", ErrUnknownAccount, account)
+	}
+	return creds, nil
+}
+
+// DefaultEnvPrefix is the prefix of the variables read by EnvProvider
+const DefaultEnvPrefix = "THREEPLAY_"
+
+// EnvProvider reads credentials from environment variables named after the
+// account: the account "sports desk" is read from THREEPLAY_SPORTS_DESK_API_KEY
+// and THREEPLAY_SPORTS_DESK_API_SECRET.
+type EnvProvider struct {
+	// Prefix replaces DefaultEnvPrefix
+	Prefix string
+	// Getenv replaces os.Getenv
+	Getenv func(key string) string
+}
+
+// Credentials implements CredentialsProvider
+func (p EnvProvider) Credentials(ctx context.Context, account string) (Credentials, error) {
+	prefix, getenv := p.Prefix, p.Getenv
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	name := prefix + envName(account)
+	creds := Credentials{
+		APIKey:    getenv(name + "_API_KEY"),
+		APISecret: getenv(name + "_API_SECRET"),
+	}
+	if creds.APIKey == "" {
+		return Credentials{}, fmt.Errorf("%w: %q (%s_API_KEY is not set)", ErrUnknownAccount, account, name)
+	}
+	return creds, nil
+}
+
+// envName upper-cases the account name and replaces the characters not
+// allowed in variable names with underscores
+func envName(account string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, account)
+}
+
+// FileProvider reads credentials from a JSON file mapping account names to
+// their api_key and api_secret:
+//
+//	{"sports": {"api_key": "...", "api_secret": "..."}}
+//
+// The file is read again whenever it changes, so credentials can be rotated
+// by replacing it. Replace it atomically, e.g. by renaming a new file over it:
+// when a new version can't be read the previous credentials keep being
+// served.
+type FileProvider struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	accounts map[string]Credentials
+}
+
+// NewFileProvider returns a provider reading the file at path
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+// Credentials implements CredentialsProvider
+func (p *FileProvider) Credentials(ctx context.Context, account string) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.reload(); err != nil && p.accounts == nil {
+		return Credentials{}, err
+	}
+	creds, ok := p.accounts[account]
+	if !ok {
+		return Credentials{}, fmt.Errorf("%w: %q", ErrUnknownAccount, account)
+	}
+	return creds, nil
+}
+
+// reload reads the file when it changed since it was last read. It must be
+// called with p.mu held.
+func (p *FileProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	if p.accounts != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return nil
+	}
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	accounts := map[string]Credentials{}
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return fmt.Errorf("invalid credentials file %s: %v", p.path, err)
+	}
+	p.accounts, p.modTime, p.size = accounts, info.ModTime(), info.Size()
+	return nil
+}
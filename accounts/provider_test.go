@@ -0,0 +1,84 @@
+package accounts_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticProvider(t *testing.T) {
+	assert := assert.New(t)
+	provider := accounts.StaticProvider{"news": {APIKey: "news-key", APISecret: "news-secret"}}
+
+	creds, err := provider.Credentials(context.Background(), "news")
+	assert.Nil(err)
+	assert.Equal(accounts.Credentials{APIKey: "news-key", APISecret: "news-secret"}, creds)
+
+	_, err = provider.Credentials(context.Background(), "sports")
+	assert.True(errors.Is(err, accounts.ErrUnknownAccount))
+}
+
+func TestEnvProvider(t *testing.T) {
+	assert := assert.New(t)
+	env := map[string]string{
+		"THREEPLAY_SPORTS_DESK_API_KEY":    "sports-key",
+		"THREEPLAY_SPORTS_DESK_API_SECRET": "sports-secret",
+		"DESK_NEWS_API_KEY":                "news-key",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	creds, err := accounts.EnvProvider{Getenv: getenv}.Credentials(context.Background(), "sports-desk")
+	assert.Nil(err)
+	assert.Equal(accounts.Credentials{APIKey: "sports-key", APISecret: "sports-secret"}, creds)
+
+	creds, err = accounts.EnvProvider{Prefix: "DESK_", Getenv: getenv}.Credentials(context.Background(), "news")
+	assert.Nil(err)
+	assert.Equal("news-key", creds.APIKey)
+
+	_, err = accounts.EnvProvider{Getenv: getenv}.Credentials(context.Background(), "news")
+	assert.True(errors.Is(err, accounts.ErrUnknownAccount))
+	assert.Contains(err.Error(), "THREEPLAY_NEWS_API_KEY")
+}
+
+func TestFileProviderRotation(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "accounts")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "accounts.json")
+	provider := accounts.NewFileProvider(path)
+
+	_, err = provider.Credentials(context.Background(), "news")
+	assert.True(os.IsNotExist(err))
+
+	write := func(content string, modTime time.Time) {
+		assert.Nil(ioutil.WriteFile(path, []byte(content), 0600))
+		assert.Nil(os.Chtimes(path, modTime, modTime))
+	}
+	now := time.Now()
+	write(`{"news": {"api_key": "key-1", "api_secret": "secret-1"}}`, now.Add(-time.Hour))
+	creds, err := provider.Credentials(context.Background(), "news")
+	assert.Nil(err)
+	assert.Equal(accounts.Credentials{APIKey: "key-1", APISecret: "secret-1"}, creds)
+
+	write(`{"news": {"api_key": "key-2", "api_secret": "secret-2"}}`, now)
+	creds, err = provider.Credentials(context.Background(), "news")
+	assert.Nil(err)
+	assert.Equal("key-2", creds.APIKey)
+
+	// a broken update keeps the last credentials
+	write(`{"news": `, now.Add(time.Hour))
+	creds, err = provider.Credentials(context.Background(), "news")
+	assert.Nil(err)
+	assert.Equal("key-2", creds.APIKey)
+
+	_, err = provider.Credentials(context.Background(), "sports")
+	assert.True(errors.Is(err, accounts.ErrUnknownAccount))
+}
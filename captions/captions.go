@@ -0,0 +1,170 @@
+// Package captions parses caption files into a common cue model.
+package captions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// ErrUnsupportedFormat is returned by Parse for formats without a parser
+var ErrUnsupportedFormat = errors.New("unsupported captions format")
+
+// Cue is a caption displayed between Start and End
+type Cue struct {
+	// ID is the SRT sequence number or the WebVTT cue identifier, if any
+	ID string
+	// Start and End are offsets from the beginning of the media
+	Start time.Duration
+	End   time.Duration
+	// Lines are the lines of text of the cue, without the speaker
+	Lines []string
+	// Speaker is the name of the speaker, taken from WebVTT voice tags, TTML
+	// agents or a leading "NAME:" label
+	Speaker string
+	// Settings are the WebVTT cue settings, e.g. "align:start line:0"
+	Settings string
+}
+
+// Text returns the lines of the cue joined by new lines
+func (c Cue) Text() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+// ParseError reports a malformed caption file
+type ParseError struct {
+	Format types.CaptionsFormat
+	// Line is the 1-based line of the error
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: line %d: %s", e.Format, e.Line, e.Msg)
+}
+
+// Parse parses captions in one of the supported formats: SRT, WebVTT and
+// DFXP/TTML
+func Parse(r io.Reader, format types.CaptionsFormat) ([]Cue, error) {
+	switch format {
+	case types.SRT:
+		return ParseSRT(r)
+	case types.WebVTT:
+		return ParseWebVTT(r)
+	case types.DFX, DFXP:
+		return ParseDFXP(r)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// DFXP is the plain DFXP format. types.DFX, the 3Play default, is parsed the
+// same way.
+const DFXP types.CaptionsFormat = "dfxp"
+
+// readLines reads r into lines, dropping a byte order mark and carriage
+// returns
+func readLines(r io.Reader) ([]string, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	data := bytes.TrimPrefix(buf.Bytes(), []byte("\xef\xbb\xbf"))
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	text = strings.Replace(text, "\r", "\n", -1)
+	return strings.Split(text, "\n"), nil
+}
+
+// speakerLabel matches the "NAME:" label 3Play puts in front of speaker
+// changes, optionally after the ">>" marker
+var speakerLabel = regexp.MustCompile(`^(?:>>\s*)?([A-Z][A-Z0-9 .'\-]*):\s+`)
+
+// splitSpeaker takes the speaker label off the first line of a cue
+func splitSpeaker(lines []string) (string, []string) {
+	if len(lines) == 0 {
+		return "", lines
+	}
+	m := speakerLabel.FindStringSubmatchIndex(lines[0])
+	if m == nil {
+		return "", lines
+	}
+	speaker := lines[0][m[2]:m[3]]
+	out := append([]string{lines[0][m[1]:]}, lines[1:]...)
+	return speaker, out
+}
+
+// parseTiming parses a "start --> end [settings]" line. SRT uses sep ',' but
+// '.' is accepted too, since it's a common variation.
+func parseTiming(line string, sep byte) (start, end time.Duration, settings string, err error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, "", fmt.Errorf("invalid timing %q", line)
+	}
+	rest := strings.Fields(parts[1])
+	if len(rest) == 0 {
+		return 0, 0, "", fmt.Errorf("missing end time in %q", line)
+	}
+	var ok bool
+	if start, ok = parseTimestamp(strings.TrimSpace(parts[0]), sep); !ok {
+		return 0, 0, "", fmt.Errorf("invalid start time %q", strings.TrimSpace(parts[0]))
+	}
+	if end, ok = parseTimestamp(rest[0], sep); !ok {
+		return 0, 0, "", fmt.Errorf("invalid end time %q", rest[0])
+	}
+	if end < start {
+		return 0, 0, "", fmt.Errorf("cue ends at %v, before it starts at %v", end, start)
+	}
+	return start, end, strings.Join(rest[1:], " "), nil
+}
+
+func parseTimestamp(s string, sep byte) (time.Duration, bool) {
+	if d, ok := parseClock(s, sep); ok || sep != ',' {
+		return d, ok
+	}
+	return parseClock(s, '.')
+}
+
+// parseClock parses "hh:mm:ss.mmm" timestamps, where the hours are optional
+// and sep separates the milliseconds
+func parseClock(s string, sep byte) (time.Duration, bool) {
+	i := strings.LastIndexByte(s, sep)
+	if i < 0 || len(s)-i-1 != 3 {
+		return 0, false
+	}
+	ms, ok := atoi(s[i+1:])
+	if !ok {
+		return 0, false
+	}
+	parts := strings.Split(s[:i], ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var total int
+	for j, part := range parts {
+		n, ok := atoi(part)
+		if !ok || (j > 0 && (len(part) != 2 || n > 59)) {
+			return 0, false
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total)*time.Second + time.Duration(ms)*time.Millisecond, true
+}
+
+func atoi(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	n := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
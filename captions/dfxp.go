@@ -0,0 +1,259 @@
+package captions
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default TTML timing parameters, used when the tt element doesn't set them
+const (
+	defaultFrameRate = 30
+	defaultTickRate  = 1
+)
+
+var (
+	clockTime  = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})(?:(\.\d+)|:(\d{2,})(?:\.(\d+))?)?$`)
+	offsetTime = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|m|s|ms|f|t)$`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// ParseDFXP parses DFXP/TTML captions. Cues are taken from the timed p
+// elements, with br elements breaking lines. Timing of enclosing body and div
+// elements is applied to their children.
+func ParseDFXP(r io.Reader) ([]Cue, error) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	// lineAt counts lines incrementally, since offsets only move forward
+	current, counted := 1, int64(0)
+	lineAt := func(offset int64) int {
+		current += bytes.Count(data[counted:offset], []byte("\n"))
+		counted = offset
+		return current
+	}
+	fail := func(line int, format string, args ...interface{}) error {
+		return &ParseError{Format: DFXP, Line: line, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	p := dfxpParser{frameRate: defaultFrameRate, tickRate: defaultTickRate}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		cues    []Cue
+		begins  []time.Duration
+		cue     *Cue
+		cueLine int
+		sawRoot bool
+	)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return nil, fail(syntaxErr.Line, "%s", syntaxErr.Msg)
+			}
+			return nil, fail(lineAt(offset), "%v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line := lineAt(offset)
+			if !sawRoot {
+				if t.Name.Local != "tt" {
+					return nil, fail(line, "root element is %s, not tt", t.Name.Local)
+				}
+				sawRoot = true
+				if err := p.setParameters(t); err != nil {
+					return nil, fail(line, "%v", err)
+				}
+				continue
+			}
+			parent := time.Duration(0)
+			if len(begins) > 0 {
+				parent = begins[len(begins)-1]
+			}
+			begin, err := p.offset(attr(t, "begin"), parent)
+			if err != nil {
+				return nil, fail(line, "%v", err)
+			}
+			begins = append(begins, begin)
+
+			switch {
+			case t.Name.Local == "p" && cue == nil:
+				c, err := p.cue(t, parent, begin)
+				if err != nil {
+					return nil, fail(line, "%v", err)
+				}
+				cue, cueLine = &c, line
+			case t.Name.Local == "br" && cue != nil:
+				cue.Lines = append(cue.Lines, "")
+			}
+		case xml.EndElement:
+			if len(begins) > 0 {
+				begins = begins[:len(begins)-1]
+			}
+			if t.Name.Local == "p" && cue != nil {
+				if cue.End < cue.Start {
+					return nil, fail(cueLine, "cue ends at %v, before it starts at %v", cue.End, cue.Start)
+				}
+				cues = append(cues, finishDFXPCue(*cue))
+				cue = nil
+			}
+		case xml.CharData:
+			if cue != nil {
+				last := len(cue.Lines) - 1
+				cue.Lines[last] += whitespace.ReplaceAllString(string(t), " ")
+			}
+		}
+	}
+	if !sawRoot {
+		return nil, fail(1, "missing tt root element")
+	}
+	return cues, nil
+}
+
+// dfxpParser holds the timing parameters of a document
+type dfxpParser struct {
+	frameRate float64
+	tickRate  float64
+}
+
+func (p *dfxpParser) setParameters(tt xml.StartElement) error {
+	if value := attr(tt, "frameRate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("invalid frameRate %q", value)
+		}
+		p.frameRate = rate
+		if value := attr(tt, "frameRateMultiplier"); value != "" {
+			var num, den float64
+			if _, err := fmt.Sscanf(value, "%g %g", &num, &den); err != nil || num <= 0 || den <= 0 {
+				return fmt.Errorf("invalid frameRateMultiplier %q", value)
+			}
+			p.frameRate *= num / den
+		}
+	}
+	if value := attr(tt, "tickRate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("invalid tickRate %q", value)
+		}
+		p.tickRate = rate
+	}
+	return nil
+}
+
+// cue starts a cue from a p element. Like begin, end is relative to the begin
+// of the parent.
+func (p *dfxpParser) cue(el xml.StartElement, parent, begin time.Duration) (Cue, error) {
+	cue := Cue{ID: attr(el, "id"), Start: begin, Speaker: attr(el, "agent"), Lines: []string{""}}
+	switch end, dur := attr(el, "end"), attr(el, "dur"); {
+	case end != "":
+		d, err := p.duration(end)
+		if err != nil {
+			return cue, err
+		}
+		cue.End = parent + d
+	case dur != "":
+		d, err := p.duration(dur)
+		if err != nil {
+			return cue, err
+		}
+		cue.End = begin + d
+	default:
+		return cue, errors.New("p element without end or dur")
+	}
+	return cue, nil
+}
+
+// offset returns the begin of an element from its begin attribute and the
+// begin of its parent
+func (p *dfxpParser) offset(value string, parent time.Duration) (time.Duration, error) {
+	if value == "" {
+		return parent, nil
+	}
+	d, err := p.duration(value)
+	return parent + d, err
+}
+
+// duration parses TTML clock and offset time expressions
+func (p *dfxpParser) duration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if m := clockTime.FindStringSubmatch(value); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		min, _ := strconv.Atoi(m[2])
+		sec, _ := strconv.Atoi(m[3])
+		seconds := float64(h*3600 + min*60 + sec)
+		if min > 59 || sec > 59 {
+			return 0, fmt.Errorf("invalid time expression %q", value)
+		}
+		if m[4] != "" {
+			fraction, _ := strconv.ParseFloat(m[4], 64)
+			seconds += fraction
+		}
+		if m[5] != "" {
+			frames, _ := strconv.ParseFloat(m[5]+"."+m[6], 64)
+			seconds += frames / p.frameRate
+		}
+		return seconds2duration(seconds), nil
+	}
+	if m := offsetTime.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2] {
+		case "h":
+			n *= 3600
+		case "m":
+			n *= 60
+		case "ms":
+			n /= 1000
+		case "f":
+			n /= p.frameRate
+		case "t":
+			n /= p.tickRate
+		}
+		return seconds2duration(n), nil
+	}
+	return 0, fmt.Errorf("invalid time expression %q", value)
+}
+
+func seconds2duration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond
+}
+
+// attr returns the value of an attribute by local name, in any namespace
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// finishDFXPCue trims the lines of a cue and takes the speaker label off
+func finishDFXPCue(cue Cue) Cue {
+	var lines []string
+	for _, line := range cue.Lines {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	cue.Lines = lines
+	if cue.Speaker == "" {
+		cue.Speaker, cue.Lines = splitSpeaker(cue.Lines)
+	}
+	return cue
+}
@@ -0,0 +1,71 @@
+package captions_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDFXP(t *testing.T) {
+	assert := assert.New(t)
+	input := `<?xml version="1.0" encoding="utf-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter"
+    xmlns:ttm="http://www.w3.org/ns/ttml#metadata" ttp:frameRate="25" ttp:tickRate="10000000">
+  <body>
+    <div begin="10s">
+      <p xml:id="c1" begin="00:00:01.000" end="00:00:02.500">
+        JANE DOE: Hello
+        <br/>there <span tts:fontStyle="italic">friend</span>
+      </p>
+      <p begin="3s" dur="500ms" ttm:agent="bob">Hi.</p>
+    </div>
+    <div>
+      <p begin="00:01:00:05" end="612000000t">Frames and ticks.</p>
+    </div>
+  </body>
+</tt>`
+	cues, err := captions.Parse(strings.NewReader(input), types.DFX)
+	assert.Nil(err)
+	assert.Equal([]captions.Cue{
+		{ID: "c1", Start: 11 * time.Second, End: 12500 * time.Millisecond, Speaker: "JANE DOE", Lines: []string{"Hello", "there friend"}},
+		{Start: 13 * time.Second, End: 13500 * time.Millisecond, Speaker: "bob", Lines: []string{"Hi."}},
+		{Start: 60200 * time.Millisecond, End: 61200 * time.Millisecond, Lines: []string{"Frames and ticks."}},
+	}, cues)
+}
+
+func TestParseDFXPErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"not ttml", "<html>\n</html>", "dfxp: line 1: root element is html, not tt"},
+		{"malformed", "<tt>\n<body>\n<p begin=\"1s\" end=\"2s\">hi</div>\n</body></tt>", "dfxp: line 3: element <p> closed by </div>"},
+		{"missing end", "<tt>\n<body>\n  <p begin=\"1s\">hi</p>\n</body></tt>", "dfxp: line 3: p element without end or dur"},
+		{"bad time", "<tt>\n<body>\n  <p begin=\"soon\" end=\"2s\">hi</p>\n</body></tt>", `dfxp: line 3: invalid time expression "soon"`},
+		{"inverted", "<tt>\n\n<body><p begin=\"3s\" end=\"2s\">hi</p></body></tt>", "dfxp: line 3: cue ends at 2s, before it starts at 3s"},
+		{"empty", "", "dfxp: line 1: missing tt root element"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := captions.ParseDFXP(strings.NewReader(tt.input))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseDFXPErrorLineInLongFile(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("<tt>\n<body>\n")
+	for i := 0; i < 20000; i++ {
+		b.WriteString("  <p begin=\"1s\" end=\"2s\">hi<br/>\nthere</p>\n")
+	}
+	b.WriteString("  <p begin=\"soon\" end=\"2s\">hi</p>\n</body></tt>")
+
+	_, err := captions.ParseDFXP(strings.NewReader(b.String()))
+	assert.EqualError(t, err, `dfxp: line 40003: invalid time expression "soon"`)
+}
@@ -0,0 +1,50 @@
+package captions
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// ParseSRT parses SubRip captions
+func ParseSRT(r io.Reader) ([]Cue, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(line int, format string, args ...interface{}) error {
+		return &ParseError{Format: types.SRT, Line: line + 1, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	var cues []Cue
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+		cue := Cue{}
+		if !strings.Contains(lines[i], "-->") {
+			cue.ID = strings.TrimSpace(lines[i])
+			if _, ok := atoi(cue.ID); !ok {
+				return nil, fail(i, "invalid sequence number %q", cue.ID)
+			}
+			i++
+			if i == len(lines) || strings.TrimSpace(lines[i]) == "" {
+				return nil, fail(i, "missing timing after sequence number %s", cue.ID)
+			}
+		}
+		if cue.Start, cue.End, _, err = parseTiming(lines[i], ','); err != nil {
+			return nil, fail(i, "%v", err)
+		}
+		i++
+		var text []string
+		for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			text = append(text, lines[i])
+		}
+		cue.Speaker, cue.Lines = splitSpeaker(text)
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
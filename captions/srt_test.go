@@ -0,0 +1,54 @@
+package captions_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSRT(t *testing.T) {
+	assert := assert.New(t)
+	input := "\xef\xbb\xbf1\r\n00:00:00,000 --> 00:00:02,800\r\n>> JANE DOE: Hello there,\r\nhow are you?\r\n\r\n" +
+		"2\n00:00:03.100 --> 01:00:04,250\nFine.\n\n\n"
+	cues, err := captions.ParseSRT(strings.NewReader(input))
+	assert.Nil(err)
+	assert.Equal([]captions.Cue{
+		{ID: "1", Start: 0, End: 2800 * time.Millisecond, Speaker: "JANE DOE", Lines: []string{"Hello there,", "how are you?"}},
+		{ID: "2", Start: 3100 * time.Millisecond, End: time.Hour + 4250*time.Millisecond, Lines: []string{"Fine."}},
+	}, cues)
+	assert.Equal("Hello there,\nhow are you?", cues[0].Text())
+}
+
+func TestParseSRTErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"bad sequence number", "one\n00:00:00,000 --> 00:00:01,000\nhi\n", `srt: line 1: invalid sequence number "one"`},
+		{"missing timing", "1\n\n", "srt: line 2: missing timing after sequence number 1"},
+		{"bad start", "1\n00:00:00,000 --> 00:00:01,000\nhi\n\n2\n0:0:1 --> 00:00:02,000\n", `srt: line 6: invalid start time "0:0:1"`},
+		{"bad end", "1\n00:00:00,000 --> 00:00:61,000\n", `srt: line 2: invalid end time "00:00:61,000"`},
+		{"inverted", "1\n00:00:02,000 --> 00:00:01,000\n", "srt: line 2: cue ends at 1s, before it starts at 2s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := captions.ParseSRT(strings.NewReader(tt.input))
+			assert.EqualError(t, err, tt.err)
+			var parseErr *captions.ParseError
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, types.SRT, parseErr.Format)
+		})
+	}
+}
+
+func TestParseUnsupportedFormat(t *testing.T) {
+	_, err := captions.Parse(strings.NewReader(""), types.SMI)
+	assert.True(t, errors.Is(err, captions.ErrUnsupportedFormat))
+	assert.EqualError(t, err, `unsupported captions format: "smi"`)
+}
@@ -0,0 +1,105 @@
+package captions
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// voiceTag matches WebVTT voice spans, e.g. "<v Jane Doe>" or "<v.loud Jane>"
+var voiceTag = regexp.MustCompile(`<v(?:\.[^ \t>]*)?[ \t]+([^>]*)>`)
+
+// ParseWebVTT parses WebVTT captions. NOTE, STYLE and REGION blocks are
+// skipped.
+func ParseWebVTT(r io.Reader) ([]Cue, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(line int, format string, args ...interface{}) error {
+		return &ParseError{Format: types.WebVTT, Line: line + 1, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	if len(lines) == 0 || !isWebVTTHeader(lines[0]) {
+		return nil, fail(0, "missing WEBVTT header")
+	}
+	i := 1
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		i++
+	}
+
+	var cues []Cue
+	for i < len(lines) {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+		block := i
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+			i++
+		}
+		if isWebVTTMetadata(lines[block]) {
+			continue
+		}
+		cue, err := parseWebVTTCue(lines[block:i])
+		if err != nil {
+			return nil, fail(block+err.offset, "%v", err.err)
+		}
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+func isWebVTTHeader(line string) bool {
+	return line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT ") || strings.HasPrefix(line, "WEBVTT\t")
+}
+
+func isWebVTTMetadata(line string) bool {
+	for _, keyword := range []string{"NOTE", "STYLE", "REGION"} {
+		if line == keyword || strings.HasPrefix(line, keyword+" ") || strings.HasPrefix(line, keyword+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+// blockError is an error on a line of a block, counted from its first line
+type blockError struct {
+	offset int
+	err    error
+}
+
+// webVTTUnescaper decodes the escapes of the characters with a meaning in
+// cue text
+var webVTTUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+func parseWebVTTCue(block []string) (Cue, *blockError) {
+	cue := Cue{}
+	timing := 0
+	if !strings.Contains(block[0], "-->") {
+		cue.ID = block[0]
+		timing = 1
+		if len(block) == 1 {
+			return cue, &blockError{1, fmt.Errorf("missing timing after cue identifier %q", cue.ID)}
+		}
+	}
+	var err error
+	if cue.Start, cue.End, cue.Settings, err = parseTiming(block[timing], '.'); err != nil {
+		return cue, &blockError{timing, err}
+	}
+	for _, line := range block[timing+1:] {
+		if m := voiceTag.FindStringSubmatch(line); m != nil && cue.Speaker == "" {
+			cue.Speaker = webVTTUnescaper.Replace(strings.TrimSpace(m[1]))
+		}
+		line = voiceTag.ReplaceAllString(line, "")
+		line = strings.Replace(line, "</v>", "", -1)
+		cue.Lines = append(cue.Lines, webVTTUnescaper.Replace(line))
+	}
+	if cue.Speaker == "" {
+		cue.Speaker, cue.Lines = splitSpeaker(cue.Lines)
+	}
+	return cue, nil
+}
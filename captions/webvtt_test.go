@@ -0,0 +1,63 @@
+package captions_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWebVTT(t *testing.T) {
+	assert := assert.New(t)
+	input := `WEBVTT - sample
+Kind: captions
+
+NOTE this is a comment
+spanning lines
+
+STYLE
+::cue { color: yellow }
+
+intro
+00:01.000 --> 00:02.500 align:start line:0
+<v.loud Jane Doe>Hello</v>
+there
+
+00:00:03.000 --> 00:00:04.000
+BOB: Hi.
+`
+	cues, err := captions.Parse(strings.NewReader(input), types.WebVTT)
+	assert.Nil(err)
+	assert.Equal([]captions.Cue{
+		{
+			ID:       "intro",
+			Start:    time.Second,
+			End:      2500 * time.Millisecond,
+			Settings: "align:start line:0",
+			Speaker:  "Jane Doe",
+			Lines:    []string{"Hello", "there"},
+		},
+		{Start: 3 * time.Second, End: 4 * time.Second, Speaker: "BOB", Lines: []string{"Hi."}},
+	}, cues)
+}
+
+func TestParseWebVTTErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"missing header", "00:01.000 --> 00:02.000\nhi\n", "vtt: line 1: missing WEBVTT header"},
+		{"missing timing", "WEBVTT\n\nintro\n", `vtt: line 4: missing timing after cue identifier "intro"`},
+		{"comma separator", "WEBVTT\n\n00:01.000 --> 00:02.000\nhi\n\nid\n00:03,000 --> 00:04.000\n", `vtt: line 7: invalid start time "00:03,000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := captions.ParseWebVTT(strings.NewReader(tt.input))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package captions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// Write writes cues in one of the formats supported by WriteSRT and
+// WriteWebVTT
+func Write(w io.Writer, cues []Cue, format types.CaptionsFormat) error {
+	switch format {
+	case types.SRT:
+		return WriteSRT(w, cues)
+	case types.WebVTT:
+		return WriteWebVTT(w, cues)
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// WriteSRT writes cues as SubRip captions, numbered from 1. Cues with a
+// Speaker get a "NAME:" label, the way ParseSRT reads it back.
+func WriteSRT(w io.Writer, cues []Cue) error {
+	b := bufio.NewWriter(w)
+	for i, cue := range cues {
+		fmt.Fprintf(b, "%d\n%s --> %s\n", i+1, formatTimestamp(cue.Start, ','), formatTimestamp(cue.End, ','))
+		writeLines(b, cue.Lines, cue.Speaker != "", cue.Speaker+": ")
+		b.WriteString("\n")
+	}
+	return b.Flush()
+}
+
+// WriteWebVTT writes cues as WebVTT captions. Cues with a Speaker are wrapped
+// in a voice tag, and cue IDs and settings are kept. The characters with a
+// meaning in cue text, &, < and >, are escaped.
+func WriteWebVTT(w io.Writer, cues []Cue) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		if cue.ID != "" {
+			b.WriteString(cue.ID + "\n")
+		}
+		fmt.Fprintf(b, "%s --> %s", formatTimestamp(cue.Start, '.'), formatTimestamp(cue.End, '.'))
+		if cue.Settings != "" {
+			b.WriteString(" " + cue.Settings)
+		}
+		b.WriteString("\n")
+		lines := make([]string, len(cue.Lines))
+		for i, line := range cue.Lines {
+			lines[i] = webVTTEscaper.Replace(line)
+		}
+		writeLines(b, lines, cue.Speaker != "", "<v "+webVTTEscaper.Replace(cue.Speaker)+">")
+		b.WriteString("\n")
+	}
+	return b.Flush()
+}
+
+var webVTTEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// writeLines writes the lines of a cue, with the speaker label in front of
+// the first one when the cue has a speaker
+func writeLines(b *bufio.Writer, lines []string, speaker bool, label string) {
+	for i, line := range lines {
+		if i == 0 && speaker {
+			b.WriteString(label)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+func formatTimestamp(d time.Duration, sep byte) string {
+	ms := int64(d / time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
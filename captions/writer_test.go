@@ -0,0 +1,62 @@
+package captions_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var writtenCues = []captions.Cue{
+	{Start: 0, End: 2800 * time.Millisecond, Speaker: "JANE DOE", Lines: []string{"Hello there,", "how are you?"}},
+	{ID: "2", Start: 3100 * time.Millisecond, End: time.Hour + 4250*time.Millisecond, Settings: "align:start", Lines: []string{"Fine."}},
+}
+
+func TestWriteSRT(t *testing.T) {
+	assert := assert.New(t)
+	var b bytes.Buffer
+	assert.Nil(captions.Write(&b, writtenCues, types.SRT))
+	assert.Equal("1\n00:00:00,000 --> 00:00:02,800\nJANE DOE: Hello there,\nhow are you?\n\n"+
+		"2\n00:00:03,100 --> 01:00:04,250\nFine.\n\n", b.String())
+
+	cues, err := captions.ParseSRT(&b)
+	assert.Nil(err)
+	assert.Equal(writtenCues[0].Lines, cues[0].Lines)
+	assert.Equal("JANE DOE", cues[0].Speaker)
+}
+
+func TestWriteWebVTT(t *testing.T) {
+	assert := assert.New(t)
+	var b bytes.Buffer
+	assert.Nil(captions.Write(&b, writtenCues, types.WebVTT))
+	assert.Equal("WEBVTT\n\n00:00:00.000 --> 00:00:02.800\n<v JANE DOE>Hello there,\nhow are you?\n\n"+
+		"2\n00:00:03.100 --> 01:00:04.250 align:start\nFine.\n\n", b.String())
+
+	cues, err := captions.ParseWebVTT(&b)
+	assert.Nil(err)
+	assert.Equal(writtenCues, cues)
+}
+
+func TestWriteWebVTTEscapes(t *testing.T) {
+	assert := assert.New(t)
+	escaped := []captions.Cue{
+		{Start: 0, End: time.Second, Speaker: "Q&A <host>", Lines: []string{"Tom & Jerry", "a <b> c > d &amp;"}},
+	}
+	var b bytes.Buffer
+	assert.Nil(captions.WriteWebVTT(&b, escaped))
+	assert.Equal("WEBVTT\n\n00:00:00.000 --> 00:00:01.000\n"+
+		"<v Q&amp;A &lt;host&gt;>Tom &amp; Jerry\na &lt;b&gt; c &gt; d &amp;amp;\n\n", b.String())
+
+	cues, err := captions.ParseWebVTT(&b)
+	assert.Nil(err)
+	assert.Equal(escaped, cues)
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	err := captions.Write(&bytes.Buffer{}, writtenCues, types.STL)
+	assert.True(t, errors.Is(err, captions.ErrUnsupportedFormat))
+}
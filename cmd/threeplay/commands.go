@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// app holds the clients and outputs shared by the commands
+type app struct {
+	v2     *v2api.Client
+	v3     *v3api.Client
+	print  printer
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func newApp(c config, print printer, stdout, stderr io.Writer) *app {
+	var v2Options []v2api.Option
+	var v3Options []v3api.Option
+	if c.BaseURL != "" {
+		v2Options = append(v2Options, v2api.WithBaseURL(c.BaseURL))
+		v3Options = append(v3Options, v3api.WithBaseURL(c.BaseURL))
+	}
+	if c.StaticBaseURL != "" {
+		v2Options = append(v2Options, v2api.WithStaticBaseURL(c.StaticBaseURL))
+	}
+	return &app{
+		v2:     v2api.NewClient(c.APIKey, c.APISecret, v2Options...),
+		v3:     v3api.NewClient(c.APIKey, v3Options...),
+		print:  print,
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+var captionsFormats = map[types.CaptionsFormat]bool{
+	types.SRT: true, types.WebVTT: true, types.DFX: true, types.SMI: true, types.STL: true,
+	types.QT: true, types.QTXML: true, types.CPTXML: true, types.ADBE: true,
+}
+
+var transcriptFormats = map[v2api.TranscriptFormat]bool{
+	v2api.JSON: true, v2api.TXT: true, v2api.HTML: true,
+}
+
+func runUpload(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("upload", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the file, defaults to the URL's base name")
+	referenceID := flags.String("reference-id", "", "reference ID of the file")
+	languageID := flags.String("language-id", "", "language ID of the media")
+	batchID := flags.String("batch-id", "", "batch (folder) ID to upload to")
+	args, err := a.parseArgs("upload", flags, args, "<url>")
+	if err != nil {
+		return err
+	}
+	options := url.Values{"source_url": {args[0]}}
+	for key, value := range map[string]string{"name": *name, "reference_id": *referenceID, "language_id": *languageID, "batch_id": *batchID} {
+		if value != "" {
+			options.Set(key, value)
+		}
+	}
+	id, err := a.v3.UploadFileFromURLContext(ctx, options, v3api.CallParams{})
+	if err != nil {
+		return err
+	}
+	return a.print(result{
+		header: []string{"media_file_id"},
+		rows:   [][]string{{strconv.Itoa(id)}},
+		value:  map[string]int{"media_file_id": id},
+	})
+}
+
+func runOrder(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("order", flag.ContinueOnError)
+	kind := flags.String("type", "asr", "asr or transcription")
+	turnaround := flags.String("turnaround", "", "turnaround level ID, required for transcription")
+	callback := flags.String("callback", "", "URL notified when the transcript is done")
+	args, err := a.parseArgs("order", flags, args, "<media-file-id>")
+	if err != nil {
+		return err
+	}
+	level := "asr"
+	switch {
+	case *kind == "transcription" && *turnaround == "":
+		return errors.New("order: -turnaround is required for transcription")
+	case *kind == "transcription":
+		level = *turnaround
+	case *kind != "asr":
+		return fmt.Errorf("order: unknown type %q", *kind)
+	}
+	transcript, err := a.v3.OrderTranscriptContext(ctx, args[0], *callback, level, v3api.CallParams{})
+	if err != nil {
+		return err
+	}
+	return a.printTranscript(transcript)
+}
+
+func runStatus(ctx context.Context, a *app, args []string) error {
+	args, err := a.parseArgs("status", flag.NewFlagSet("status", flag.ContinueOnError), args, "<media-file-id>")
+	if err != nil {
+		return err
+	}
+	transcript, err := a.v3.GetTranscriptInfoContext(ctx, args[0], v3api.CallParams{})
+	if err != nil {
+		return err
+	}
+	return a.printTranscript(transcript)
+}
+
+func runWait(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("wait", flag.ContinueOnError)
+	interval := flags.Duration("interval", v3api.DefaultWaitInterval, "wait before the second poll, grows after each one")
+	timeout := flags.Duration("timeout", 0, "give up after this long, 0 waits forever")
+	args, err := a.parseArgs("wait", flags, args, "<media-file-id>")
+	if err != nil {
+		return err
+	}
+	transcript, err := a.v3.WaitForTranscript(ctx, args[0], v3api.WaitOptions{
+		Interval: *interval,
+		MaxWait:  *timeout,
+		OnStatusChange: func(t *v3api.TranscriptObjectRepresentation) {
+			fmt.Fprintf(a.stderr, "%s: %s\n", time.Now().Format(time.RFC3339), t.Status)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return a.printTranscript(transcript)
+}
+
+func runDownload(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("download", flag.ContinueOnError)
+	format := flags.String("format", "", "captions format (srt, vtt, pdfxp, smi, stl, qt, qtxml, cptxml, adbe) or transcript format (json, txt, html)")
+	file := flags.String("file", "", "file to write to, defaults to stdout")
+	byVideoID := flags.Bool("video-id", false, "the argument is a video ID rather than a file ID")
+	args, err := a.parseArgs("download", flags, args, "<file-id>")
+	if err != nil {
+		return err
+	}
+
+	var fileID uint64
+	if !*byVideoID {
+		if fileID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+			return fmt.Errorf("download: invalid file ID %q", args[0])
+		}
+	}
+	opts := v2api.GetCaptionsOptions{FileID: uint(fileID), Format: types.CaptionsFormat(*format)}
+	if *byVideoID {
+		opts.VideoID = args[0]
+	}
+	var download func(w io.Writer) (int64, error)
+	switch {
+	case transcriptFormats[v2api.TranscriptFormat(*format)] && *byVideoID:
+		download = func(w io.Writer) (int64, error) {
+			return a.v2.GetTranscriptByVideoIDWithFormatTo(ctx, w, args[0], v2api.TranscriptFormat(*format))
+		}
+	case transcriptFormats[v2api.TranscriptFormat(*format)]:
+		download = func(w io.Writer) (int64, error) {
+			return a.v2.GetTranscriptWithFormatTo(ctx, w, uint(fileID), v2api.TranscriptFormat(*format))
+		}
+	case captionsFormats[types.CaptionsFormat(*format)]:
+		download = func(w io.Writer) (int64, error) {
+			return a.v2.GetCaptionsTo(ctx, w, opts)
+		}
+	default:
+		return fmt.Errorf("download: unknown format %q", *format)
+	}
+	if *file == "" {
+		_, err = download(a.stdout)
+		return err
+	}
+	return writeFile(*file, download)
+}
+
+// writeFile streams a download to a temporary file next to path, renamed to
+// path once complete, so a failed download leaves no partial file behind
+func writeFile(path string, download func(w io.Writer) (int64, error)) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = download(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func runCancel(ctx context.Context, a *app, args []string) error {
+	args, err := a.parseArgs("cancel", flag.NewFlagSet("cancel", flag.ContinueOnError), args, "<media-file-id>")
+	if err != nil {
+		return err
+	}
+	if err := a.v3.CancelTranscriptContext(ctx, args[0], v3api.CallParams{}); err != nil {
+		return err
+	}
+	return a.print(result{
+		header: []string{"media_file_id", "cancelled"},
+		rows:   [][]string{{args[0], "true"}},
+		value:  map[string]interface{}{"media_file_id": args[0], "cancelled": true},
+	})
+}
+
+func runEditingLink(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("editing-link", flag.ContinueOnError)
+	hours := flags.Int("hours", 24, "hours until the link expires")
+	args, err := a.parseArgs("editing-link", flags, args, "<media-file-id>")
+	if err != nil {
+		return err
+	}
+	link, err := a.v3.GetEditingLinkContext(ctx, args[0], *hours, v3api.CallParams{})
+	if err != nil {
+		return err
+	}
+	return a.print(result{
+		header: []string{"media_file_id", "editing_link"},
+		rows:   [][]string{{args[0], link}},
+		value:  map[string]string{"media_file_id": args[0], "editing_link": link},
+	})
+}
+
+func runFiles(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintf(a.stderr, "usage: threeplay %s\n", commands["files"].usage)
+		return errUsage
+	}
+	flags := flag.NewFlagSet("files list", flag.ContinueOnError)
+	var q v2api.FileQuery
+	flags.Var((*stringValue)(&q.State), "state", "state of the files, e.g. complete or in_progress")
+	flags.UintVar(&q.BatchID, "batch", 0, "batch (folder) ID")
+	flags.StringVar(&q.VideoID, "video-id", "", "video ID")
+	flags.StringVar(&q.Name, "name", "", "file name")
+	flags.Var((*stringValue)(&q.SortBy), "sort", "sort field: created_at, updated_at, name or duration")
+	flags.BoolVar(&q.Descending, "desc", false, "sort in descending order")
+	flags.IntVar(&q.Page, "page", 0, "page to list")
+	flags.IntVar(&q.PerPage, "per-page", 0, "files per page")
+	all := flags.Bool("all", false, "list every page, starting at -page")
+	if _, err := a.parseArgs("files", flags, args[1:]); err != nil {
+		return err
+	}
+
+	var files []v2api.File
+	if *all {
+		it, err := a.v2.IterateQuery(ctx, q)
+		if err != nil {
+			return err
+		}
+		defer it.Close()
+		for it.Next() {
+			files = append(files, it.File())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	} else {
+		page, err := a.v2.QueryFiles(ctx, q)
+		if err != nil {
+			return err
+		}
+		files = page.Files
+	}
+
+	r := result{header: []string{"id", "name", "state", "video_id", "duration", "created_at"}, value: files}
+	for _, f := range files {
+		r.rows = append(r.rows, []string{
+			strconv.FormatUint(uint64(f.ID), 10), f.Name, f.State, f.VideoID,
+			strconv.FormatUint(uint64(f.Duration), 10), f.CreatedAt,
+		})
+	}
+	if files == nil {
+		r.value = []v2api.File{}
+	}
+	return a.print(r)
+}
+
+func runTags(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(a.stderr, "usage: threeplay %s\n", commands["tags"].usage)
+		return errUsage
+	}
+	action, want := args[0], []string{"<file-id>", "<tag>"}
+	if action == "list" {
+		want = want[:1]
+	}
+	args, err := a.parseArgs("tags", flag.NewFlagSet("tags "+action, flag.ContinueOnError), args[1:], want...)
+	if err != nil {
+		return err
+	}
+	fileID, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("tags: invalid file ID %q", args[0])
+	}
+
+	var tags []string
+	switch action {
+	case "list":
+		tags, err = a.v2.GetTagsContext(ctx, uint(fileID))
+	case "add":
+		tags, err = a.v2.AddTagContext(ctx, uint(fileID), args[1])
+	case "remove":
+		tags, err = a.v2.RemoveTagContext(ctx, uint(fileID), args[1])
+	default:
+		fmt.Fprintf(a.stderr, "usage: threeplay %s\n", commands["tags"].usage)
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	r := result{header: []string{"tag"}, value: tags}
+	for _, tag := range tags {
+		r.rows = append(r.rows, []string{tag})
+	}
+	if tags == nil {
+		r.value = []string{}
+	}
+	return a.print(r)
+}
+
+func (a *app) printTranscript(t *v3api.TranscriptObjectRepresentation) error {
+	return a.print(result{
+		header: []string{"id", "media_file_id", "type", "status", "cancellation_reason"},
+		rows: [][]string{{
+			strconv.Itoa(t.ID), strconv.Itoa(t.MediaFileID), t.Type, t.Status, t.CancellationReason,
+		}},
+		value: t,
+	})
+}
+
+// stringValue is a flag.Value for the string types of the clients
+type stringValue string
+
+func (s *stringValue) String() string     { return string(*s) }
+func (s *stringValue) Set(v string) error { *s = stringValue(v); return nil }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// config holds the credentials and endpoints of the tool
+type config struct {
+	APIKey        string `json:"api_key"`
+	APISecret     string `json:"api_secret"`
+	BaseURL       string `json:"base_url"`
+	StaticBaseURL string `json:"static_base_url"`
+}
+
+// loadConfig reads the config file, if any, and applies the environment on
+// top of it. A missing file is only an error when its path was given.
+func loadConfig(path string, getenv func(string) string) (config, error) {
+	var c config
+	explicit := path != "" || getenv("THREEPLAY_CONFIG") != ""
+	if path == "" {
+		path = getenv("THREEPLAY_CONFIG")
+	}
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "threeplay", "config.json")
+		}
+	}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &c); err != nil {
+				return c, fmt.Errorf("invalid config file %s: %v", path, err)
+			}
+		case !os.IsNotExist(err) || explicit:
+			return c, err
+		}
+	}
+
+	for name, field := range map[string]*string{
+		"THREEPLAY_API_KEY":         &c.APIKey,
+		"THREEPLAY_API_SECRET":      &c.APISecret,
+		"THREEPLAY_BASE_URL":        &c.BaseURL,
+		"THREEPLAY_STATIC_BASE_URL": &c.StaticBaseURL,
+	} {
+		if value := getenv(name); value != "" {
+			*field = value
+		}
+	}
+	if c.APIKey == "" {
+		return c, fmt.Errorf("missing API key: set THREEPLAY_API_KEY or api_key in the config file")
+	}
+	return c, nil
+}
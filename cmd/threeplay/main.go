@@ -0,0 +1,135 @@
+// Command threeplay runs operational tasks against the 3Play Media API.
+//
+// Usage:
+//
+//	threeplay [-config file] [-output table|json|csv] <command> [flags] [args]
+//
+// Credentials are read from THREEPLAY_API_KEY and THREEPLAY_API_SECRET, or
+// from a JSON config file with api_key, api_secret, base_url and
+// static_base_url. Environment variables take precedence over the file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+)
+
+// errUsage reports bad arguments, after the usage was printed
+var errUsage = errors.New("usage error")
+
+// command is a subcommand of the tool
+type command struct {
+	usage string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+// commands is set up in init, since the commands refer to it for usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"upload":       {"upload [-name name] [-reference-id id] [-language-id id] [-batch-id id] <url>", runUpload},
+		"order":        {"order [-type asr|transcription] [-turnaround level] [-callback url] <media-file-id>", runOrder},
+		"status":       {"status <media-file-id>", runStatus},
+		"wait":         {"wait [-interval 30s] [-timeout 0] <media-file-id>", runWait},
+		"download":     {"download -format format [-file path] [-video-id] <file-id>", runDownload},
+		"cancel":       {"cancel <media-file-id>", runCancel},
+		"editing-link": {"editing-link [-hours 24] <media-file-id>", runEditingLink},
+		"files":        {"files list [-state state] [-batch id] [-video-id id] [-name name] [-sort field] [-desc] [-page n] [-per-page n] [-all]", runFiles},
+		"tags":         {"tags list|add|remove <file-id> [tag]", runTags},
+	}
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
+	os.Exit(run(ctx, os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// run runs the tool and returns its exit code: 0 on success, 1 on errors
+// and 2 on usage errors
+func run(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("threeplay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "config file, defaults to $THREEPLAY_CONFIG or threeplay/config.json in the user config dir")
+	output := flags.String("output", "table", "output mode: table, json or csv")
+	flags.Usage = func() { usage(flags, stderr) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		usage(flags, stderr)
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "threeplay: unknown command %q\n", flags.Arg(0))
+		usage(flags, stderr)
+		return 2
+	}
+	printer, err := newPrinter(*output, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "threeplay: %v\n", err)
+		return 2
+	}
+	config, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "threeplay: %v\n", err)
+		return 1
+	}
+	a := newApp(config, printer, stdout, stderr)
+	if err := cmd.run(ctx, a, flags.Args()[1:]); err != nil {
+		if err == errUsage || err == flag.ErrHelp {
+			return 2
+		}
+		fmt.Fprintf(stderr, "threeplay: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func usage(flags *flag.FlagSet, w io.Writer) {
+	fmt.Fprintln(w, "usage: threeplay [-config file] [-output table|json|csv] <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nflags:")
+	flags.PrintDefaults()
+}
+
+// parseArgs parses the flags of a command and checks it got want arguments
+func (a *app) parseArgs(name string, flags *flag.FlagSet, args []string, want ...string) ([]string, error) {
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: threeplay %s\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	if flags.NArg() != len(want) {
+		fmt.Fprintf(a.stderr, "threeplay %s: expected %s\n", name, strings.Join(want, " "))
+		flags.Usage()
+		return nil, errUsage
+	}
+	return flags.Args(), nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// cli runs the tool against a fake server
+type cli struct {
+	t      *testing.T
+	server *threeplaytest.Server
+	env    map[string]string
+	// dir is a temporary directory removed by close
+	dir string
+}
+
+func newCLI(t *testing.T) *cli {
+	dir, err := ioutil.TempDir("", "threeplay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an empty config file keeps the user's own config out of the tests
+	config := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(config, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	server := threeplaytest.NewServer()
+	return &cli{t: t, server: server, dir: dir, env: map[string]string{
+		"THREEPLAY_CONFIG":          config,
+		"THREEPLAY_API_KEY":         server.APIKey,
+		"THREEPLAY_API_SECRET":      server.APISecret,
+		"THREEPLAY_BASE_URL":        server.URL,
+		"THREEPLAY_STATIC_BASE_URL": server.StaticURL(),
+	}}
+}
+
+func (c *cli) close() {
+	c.server.Close()
+	os.RemoveAll(c.dir)
+}
+
+func (c *cli) run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, func(name string) string { return c.env[name] }, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// mustRun runs the tool and fails the test unless it succeeds
+func (c *cli) mustRun(args ...string) string {
+	code, stdout, stderr := c.run(args...)
+	if code != 0 {
+		c.t.Fatalf("threeplay %s: exit code %d: %s", strings.Join(args, " "), code, stderr)
+	}
+	return stdout
+}
+
+func TestUploadOrderWaitDownload(t *testing.T) {
+	assert := assert.New(t)
+	c := newCLI(t)
+	defer c.close()
+	var upload map[string]int
+	assert.Nil(json.Unmarshal([]byte(c.mustRun("-output", "json", "upload", "-name", "speech", "https://somewhere.com/speech.mp4")), &upload))
+	id := upload["media_file_id"]
+	assert.NotZero(id)
+	fileID := strconv.Itoa(id)
+	assert.Contains(c.mustRun("-output", "csv", "files", "list", "-name", "speech"), fileID+",speech,")
+
+	assert.Contains(c.mustRun("order", fileID), "pending")
+	assert.Contains(c.mustRun("status", fileID), fileID)
+
+	assert.Nil(c.server.CompleteTranscript(id))
+	assert.Contains(c.mustRun("wait", "-interval", "1ms", fileID), "complete")
+
+	srt := c.mustRun("download", "-format", "srt", fileID)
+	assert.True(strings.HasPrefix(srt, "1\n00:00:00,000 --> "))
+	assert.Contains(c.mustRun("download", "-format", "txt", fileID), "speech")
+
+	path := filepath.Join(c.dir, "captions.vtt")
+	assert.Equal("", c.mustRun("download", "-format", "vtt", "-file", path, fileID))
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(string(data), "WEBVTT"))
+
+	assert.Contains(c.mustRun("editing-link", "-hours", "2", fileID), "exp_key=2")
+}
+
+func TestFilesListOutputModes(t *testing.T) {
+	assert := assert.New(t)
+	c := newCLI(t)
+	defer c.close()
+	c.server.AddFile("first")
+	c.server.AddFile("second")
+
+	table := c.mustRun("files", "list")
+	assert.True(strings.HasPrefix(table, "ID  "))
+	assert.Contains(table, "first")
+
+	csv := c.mustRun("-output", "csv", "files", "list", "-per-page", "1", "-all")
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	assert.Equal("id,name,state,video_id,duration,created_at", lines[0])
+	assert.Len(lines, 3)
+
+	var files []map[string]interface{}
+	assert.Nil(json.Unmarshal([]byte(c.mustRun("-output", "json", "files", "list", "-name", "second")), &files))
+	assert.Len(files, 1)
+	assert.Equal("second", files[0]["name"])
+
+	code, _, stderr := c.run("files", "list", "-state", "bogus")
+	assert.Equal(1, code)
+	assert.Contains(stderr, `unknown state "bogus"`)
+}
+
+func TestTags(t *testing.T) {
+	assert := assert.New(t)
+	c := newCLI(t)
+	defer c.close()
+	id := strconv.Itoa(c.server.AddFile("tagged"))
+
+	assert.Equal("[]\n", c.mustRun("-output", "json", "tags", "list", id))
+	c.mustRun("tags", "add", id, "news")
+	c.mustRun("tags", "add", id, "video")
+	assert.Equal("tag\nnews\nvideo\n", c.mustRun("-output", "csv", "tags", "list", id))
+	assert.Equal("tag\nvideo\n", c.mustRun("-output", "csv", "tags", "remove", id, "news"))
+}
+
+func TestCancel(t *testing.T) {
+	assert := assert.New(t)
+	c := newCLI(t)
+	defer c.close()
+	id := strconv.Itoa(c.server.AddFile("speech.mp4"))
+	c.mustRun("order", "-type", "transcription", "-turnaround", "2", id)
+
+	assert.Equal("media_file_id,cancelled\n"+id+",true\n", c.mustRun("-output", "csv", "cancel", id))
+	assert.Contains(c.mustRun("status", id), "cancelled")
+}
+
+func TestUsageErrors(t *testing.T) {
+	assert := assert.New(t)
+	c := newCLI(t)
+	defer c.close()
+
+	for _, args := range [][]string{
+		{},
+		{"bogus"},
+		{"status"},
+		{"tags", "add", "1"},
+		{"files"},
+	} {
+		code, _, stderr := c.run(args...)
+		assert.Equal(2, code, "%v", args)
+		assert.Contains(stderr, "usage", "%v", args)
+	}
+
+	code, _, stderr := c.run("-output", "xml", "status", "1")
+	assert.Equal(2, code)
+	assert.Contains(stderr, `unknown output mode "xml"`)
+
+	code, _, stderr = c.run("order", "-type", "transcription", "1")
+	assert.Equal(1, code)
+	assert.Contains(stderr, "-turnaround is required")
+
+	code, _, stderr = c.run("download", "-format", "doc", "1")
+	assert.Equal(1, code)
+	assert.Contains(stderr, `unknown format "doc"`)
+
+	out := filepath.Join(c.dir, "out")
+	assert.Nil(os.Mkdir(out, 0700))
+	code, _, _ = c.run("download", "-format", "srt", "-file", filepath.Join(out, "missing.srt"), "999")
+	assert.Equal(1, code)
+	entries, err := ioutil.ReadDir(out)
+	assert.Nil(err)
+	assert.Empty(entries, "a failed download leaves no file behind")
+}
+
+func TestConfigFile(t *testing.T) {
+	assert := assert.New(t)
+	c := newCLI(t)
+	defer c.close()
+	c.server.AddFile("configured")
+
+	path := filepath.Join(c.dir, "config.json")
+	config, _ := json.Marshal(map[string]string{
+		"api_key":    c.server.APIKey,
+		"api_secret": c.server.APISecret,
+		"base_url":   c.server.URL,
+	})
+	assert.Nil(ioutil.WriteFile(path, config, 0600))
+	c.env = map[string]string{}
+
+	assert.Contains(c.mustRun("-config", path, "files", "list"), "configured")
+
+	c.env["THREEPLAY_API_KEY"] = "wrong"
+	code, _, stderr := c.run("-config", path, "files", "list")
+	assert.Equal(1, code)
+	assert.Contains(stderr, "401")
+
+	code, _, stderr = c.run("-config", filepath.Join(c.dir, "missing.json"), "files", "list")
+	assert.Equal(1, code)
+	assert.Contains(stderr, "missing.json")
+}
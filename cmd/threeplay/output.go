@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// result is the output of a command: a table for the table and CSV modes,
+// and a value for the JSON mode
+type result struct {
+	header []string
+	rows   [][]string
+	value  interface{}
+}
+
+// printer writes results in one of the output modes
+type printer func(result) error
+
+func newPrinter(mode string, w io.Writer) (printer, error) {
+	switch mode {
+	case "table":
+		return func(r result) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.header, "\t")))
+			for _, row := range r.rows {
+				fmt.Fprintln(tw, strings.Join(row, "\t"))
+			}
+			return tw.Flush()
+		}, nil
+	case "csv":
+		return func(r result) error {
+			cw := csv.NewWriter(w)
+			cw.Write(r.header)
+			cw.WriteAll(r.rows)
+			return cw.Error()
+		}, nil
+	case "json":
+		return func(r result) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(r.value)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown output mode %q", mode)
+}
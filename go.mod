@@ -1,6 +1,6 @@
 module github.com/nytimes/threeplay
 
-go 1.12
+go 1.13
 
 require (
 	github.com/sethgrid/pester v0.0.0-20190127155807-68a33a018ad0
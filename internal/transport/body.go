@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize is the size above which responses read into memory fail
+// with ErrBodyTooLarge
+const DefaultMaxBodySize = 10 << 20
+
+// maxDrain bounds the unread bytes discarded when closing a body. Past it,
+// closing the connection is cheaper than reading the rest to reuse it.
+const maxDrain = 256 << 10
+
+// snippetSize bounds the bytes of a body quoted in error messages
+const snippetSize = 256
+
+// ReadBody reads the body of res and closes it. Bodies larger than limit
+// bytes fail with ErrBodyTooLarge; a limit of 0 or less reads any size.
+func ReadBody(res *http.Response, limit int64) ([]byte, error) {
+	defer CloseBody(res)
+	if limit <= 0 {
+		return ioutil.ReadAll(res.Body)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, limit)
+	}
+	return data, nil
+}
+
+// CloseBody drains what's left of the body of res, up to a bound, and closes
+// it so the connection can be reused. It accepts a nil response.
+func CloseBody(res *http.Response) {
+	if res == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxDrain))
+	res.Body.Close()
+}
+
+// SuccessStatus reports whether code is a 2xx HTTP status
+func SuccessStatus(code int) bool {
+	return code >= 200 && code < 300
+}
+
+// BodySnippet returns the start of a response body with its whitespace
+// collapsed and credentials redacted, to quote it in error messages
+func BodySnippet(body []byte) string {
+	truncated := len(body) > snippetSize
+	if truncated {
+		body = body[:snippetSize]
+	}
+	snippet := strings.Join(strings.Fields(strings.ToValidUTF8(string(body), "")), " ")
+	if truncated {
+		snippet += "..."
+	}
+	return Redact(snippet)
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type trackedBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestReadBody(t *testing.T) {
+	assert := assert.New(t)
+
+	body := &trackedBody{Reader: strings.NewReader("0123456789")}
+	data, err := ReadBody(&http.Response{Body: body}, 10)
+	assert.Nil(err)
+	assert.Equal("0123456789", string(data))
+	assert.True(body.closed)
+
+	body = &trackedBody{Reader: strings.NewReader("0123456789")}
+	data, err = ReadBody(&http.Response{Body: body}, 4)
+	assert.Nil(data)
+	assert.True(errors.Is(err, ErrBodyTooLarge))
+	assert.Equal("response body too large: more than 4 bytes", err.Error())
+	assert.True(body.closed)
+	assert.Equal(0, body.Len())
+
+	data, err = ReadBody(&http.Response{Body: ioutil.NopCloser(strings.NewReader("0123456789"))}, 0)
+	assert.Nil(err)
+	assert.Equal("0123456789", string(data))
+}
+
+func TestCloseBody(t *testing.T) {
+	assert := assert.New(t)
+
+	body := &trackedBody{Reader: strings.NewReader(strings.Repeat("x", maxDrain+10))}
+	CloseBody(&http.Response{Body: body})
+	assert.True(body.closed)
+	// bodies too large to be worth draining are left unread
+	assert.Equal(10, body.Len())
+
+	CloseBody(nil)
+}
+
+func TestBodySnippet(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", BodySnippet(nil))
+	assert.Equal("<html> <body>Bad gateway</body> </html>",
+		BodySnippet([]byte("<html>\n  <body>Bad gateway</body>\n</html>\n")))
+	assert.Equal("see /files?apikey=REDACTED", BodySnippet([]byte("see /files?apikey=secret")))
+
+	snippet := BodySnippet([]byte(strings.Repeat("a", 1000)))
+	assert.Equal(strings.Repeat("a", snippetSize)+"...", snippet)
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Event describes one attempt of a call to 3Play. Credentials are redacted
+// from URL and Err.
+type Event struct {
+	// Method is the HTTP method of the request
+	Method string
+	// Endpoint is the path of the request, e.g. /v3/transcripts/123
+	Endpoint string
+	// URL is the full request URL
+	URL string
+	// Attempt counts the attempts made for the call, starting at 1
+	Attempt int
+	// StatusCode is the status of the response, in OnResponse and OnRetry
+	StatusCode int
+	// LimitWait is the time the attempt waited for the rate limiter
+	LimitWait time.Duration
+	// Duration is the time the attempt took to get a response or fail
+	Duration time.Duration
+	// Wait is the backoff before the next attempt, in OnRetry
+	Wait time.Duration
+	// Err is the error the attempt failed with, in OnError and OnRetry
+	Err error
+	// ErrorType is the type of the error 3Play reported, in OnAPIError
+	ErrorType string
+}
+
+// Hooks observe the calls made by a client. OnRequest is called before every
+// attempt, OnResponse when the attempt got a response, whatever its status,
+// OnError when it failed without one and OnRetry before waiting for the next
+// attempt. Hooks are called synchronously and should return quickly.
+type Hooks interface {
+	OnRequest(ctx context.Context, e Event)
+	OnRetry(ctx context.Context, e Event)
+	OnResponse(ctx context.Context, e Event)
+	OnError(ctx context.Context, e Event)
+}
+
+// APIErrorHooks can be implemented by Hooks to also be told about the errors
+// 3Play reports in response payloads. OnAPIError is called once the client
+// has decoded the error, after OnResponse, with ErrorType set to the type of
+// the APIError returned to the caller.
+type APIErrorHooks interface {
+	OnAPIError(ctx context.Context, e Event)
+}
+
+// callKey is the context key under which Do stores the call in progress, so
+// the hooks can be found again from the response
+type callKey struct{}
+
+type call struct {
+	client *Client
+	event  Event
+}
+
+// ReportAPIError tells the hooks of the client that sent the request of res
+// about the error 3Play reported in the response payload.
+func ReportAPIError(res *http.Response, errorType string) {
+	if res == nil || res.Request == nil {
+		return
+	}
+	ctx := res.Request.Context()
+	current, ok := ctx.Value(callKey{}).(*call)
+	if !ok {
+		return
+	}
+	e := current.event
+	e.ErrorType = errorType
+	for _, h := range current.client.Hooks {
+		if h, ok := h.(APIErrorHooks); ok {
+			h.OnAPIError(ctx, e)
+		}
+	}
+}
+
+func newEvent(req *http.Request, attempt int) Event {
+	return Event{
+		Method:   req.Method,
+		Endpoint: req.URL.Path,
+		URL:      Redact(req.URL.String()),
+		Attempt:  attempt,
+	}
+}
+
+func (c *Client) notify(ctx context.Context, e Event, hook func(Hooks, context.Context, Event)) {
+	for _, h := range c.Hooks {
+		hook(h, ctx, e)
+	}
+}
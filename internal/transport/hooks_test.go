@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedEvent struct {
+	hook  string
+	event Event
+}
+
+type recorder struct {
+	events []recordedEvent
+}
+
+func (r *recorder) OnRequest(ctx context.Context, e Event)  { r.record("request", e) }
+func (r *recorder) OnRetry(ctx context.Context, e Event)    { r.record("retry", e) }
+func (r *recorder) OnResponse(ctx context.Context, e Event) { r.record("response", e) }
+func (r *recorder) OnError(ctx context.Context, e Event)    { r.record("error", e) }
+
+func (r *recorder) record(hook string, e Event) {
+	r.events = append(r.events, recordedEvent{hook, e})
+}
+
+func (r *recorder) hooks() []string {
+	var hooks []string
+	for _, e := range r.events {
+		hooks = append(hooks, e.hook)
+	}
+	return hooks
+}
+
+func TestHooksObserveRetries(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	rec := &recorder{}
+	client := New(server.Client())
+	client.Policy.Backoff = noBackoff
+	client.Hooks = []Hooks{rec}
+	res, err := client.Get(context.Background(), server.URL+"/v3/files?api_key=secret-key")
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	assert.Equal([]string{"request", "response", "retry", "request", "response"}, rec.hooks())
+	retry := rec.events[2].event
+	assert.Equal(http.MethodGet, retry.Method)
+	assert.Equal("/v3/files", retry.Endpoint)
+	assert.Equal(1, retry.Attempt)
+	assert.Equal(http.StatusServiceUnavailable, retry.StatusCode)
+	assert.True(retry.Duration > 0)
+	assert.Equal(2, rec.events[4].event.Attempt)
+	assert.Equal(http.StatusOK, rec.events[4].event.StatusCode)
+	for _, e := range rec.events {
+		assert.False(strings.Contains(e.event.URL, "secret-key"), e.event.URL)
+	}
+}
+
+func TestHooksObserveErrors(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL + "/files/1?apikey=secret-key"
+	server.Close()
+
+	rec := &recorder{}
+	client := New(http.DefaultClient)
+	client.Policy.MaxAttempts = 1
+	client.Hooks = []Hooks{rec}
+	_, err := client.Get(context.Background(), endpoint)
+	assert.NotNil(err)
+
+	assert.Equal([]string{"request", "error"}, rec.hooks())
+	failed := rec.events[1].event
+	assert.NotNil(failed.Err)
+	assert.NotContains(failed.Err.Error(), "secret-key")
+}
+
+type apiErrorRecorder struct {
+	recorder
+}
+
+func (r *apiErrorRecorder) OnAPIError(ctx context.Context, e Event) { r.record("api error", e) }
+
+func TestReportAPIError(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	rec := &apiErrorRecorder{}
+	client := New(server.Client())
+	client.Hooks = []Hooks{rec}
+	res, err := client.Get(context.Background(), server.URL+"/v3/transcripts/1")
+	assert.Nil(err)
+	ReportAPIError(res, "not_found_error")
+
+	assert.Equal([]string{"request", "response", "api error"}, rec.hooks())
+	reported := rec.events[2].event
+	assert.Equal("not_found_error", reported.ErrorType)
+	assert.Equal(http.StatusNotFound, reported.StatusCode)
+	assert.Equal("/v3/transcripts/1", reported.Endpoint)
+
+	// responses of clients without hooks are ignored
+	res, err = New(server.Client()).Get(context.Background(), server.URL)
+	assert.Nil(err)
+	ReportAPIError(res, "not_found_error")
+	ReportAPIError(nil, "not_found_error")
+}
@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBodyTooLarge is returned when a response body exceeds the size allowed
+// for it
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// LimitWriter returns a writer that writes to w until limit bytes were
+// written, then fails with ErrBodyTooLarge. A limit of 0 or less doesn't
+// limit writes.
+func LimitWriter(w io.Writer, limit int64) io.Writer {
+	if limit <= 0 {
+		return w
+	}
+	return &limitedWriter{w: w, left: limit, limit: limit}
+}
+
+type limitedWriter struct {
+	w     io.Writer
+	left  int64
+	limit int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= l.left {
+		n, err := l.w.Write(p)
+		l.left -= int64(n)
+		return n, err
+	}
+	n, err := l.w.Write(p[:l.left])
+	l.left -= int64(n)
+	if err == nil {
+		err = fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, l.limit)
+	}
+	return n, err
+}
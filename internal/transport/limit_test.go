@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	w := LimitWriter(&buf, 5)
+	n, err := w.Write([]byte("abc"))
+	assert.Equal(3, n)
+	assert.Nil(err)
+	n, err = w.Write([]byte("de"))
+	assert.Equal(2, n)
+	assert.Nil(err)
+	n, err = w.Write([]byte("f"))
+	assert.Equal(0, n)
+	assert.True(errors.Is(err, ErrBodyTooLarge))
+	assert.Equal("abcde", buf.String())
+
+	buf.Reset()
+	n, err = LimitWriter(&buf, 2).Write([]byte("abc"))
+	assert.Equal(2, n)
+	assert.Equal("response body too large: more than 2 bytes", err.Error())
+	assert.Equal("ab", buf.String())
+}
+
+func TestLimitWriterUnlimited(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, &buf, LimitWriter(&buf, 0))
+}
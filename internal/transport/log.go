@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+)
+
+// Logger is the structured logger LogHooks writes to. *slog.Logger satisfies
+// it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+// LogHooks returns hooks logging calls to logger: requests and responses at
+// debug level, failed attempts, throttling, server errors, retries and the
+// errors reported by 3Play at warn level.
+func LogHooks(logger Logger) Hooks {
+	return logHooks{logger}
+}
+
+type logHooks struct {
+	logger Logger
+}
+
+func (l logHooks) OnRequest(ctx context.Context, e Event) {
+	args := []interface{}{"method", e.Method, "endpoint", e.Endpoint, "attempt", e.Attempt}
+	if e.LimitWait > 0 {
+		args = append(args, "limit_wait", e.LimitWait)
+	}
+	l.logger.Debug("threeplay: request", args...)
+}
+
+func (l logHooks) OnRetry(ctx context.Context, e Event) {
+	args := []interface{}{"method", e.Method, "endpoint", e.Endpoint, "attempt", e.Attempt, "wait", e.Wait}
+	if e.Err != nil {
+		args = append(args, "error", e.Err.Error())
+	} else {
+		args = append(args, "status", e.StatusCode)
+	}
+	l.logger.Warn("threeplay: retrying", args...)
+}
+
+func (l logHooks) OnResponse(ctx context.Context, e Event) {
+	log := l.logger.Debug
+	if e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError {
+		log = l.logger.Warn
+	}
+	log("threeplay: response", "method", e.Method, "endpoint", e.Endpoint, "attempt", e.Attempt,
+		"status", e.StatusCode, "duration", e.Duration)
+}
+
+func (l logHooks) OnError(ctx context.Context, e Event) {
+	l.logger.Warn("threeplay: request failed", "method", e.Method, "endpoint", e.Endpoint, "attempt", e.Attempt,
+		"duration", e.Duration, "error", e.Err.Error())
+}
+
+func (l logHooks) OnAPIError(ctx context.Context, e Event) {
+	l.logger.Warn("threeplay: api error", "method", e.Method, "endpoint", e.Endpoint, "attempt", e.Attempt,
+		"status", e.StatusCode, "type", e.ErrorType)
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args) }
+
+func (l *testLogger) log(level, msg string, args []interface{}) {
+	line := level + " " + msg
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "duration" {
+			continue
+		}
+		line += fmt.Sprintf(" %v=%v", args[i], args[i+1])
+	}
+	l.lines = append(l.lines, line)
+}
+
+func TestLogHooks(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	logger := &testLogger{}
+	client := New(server.Client())
+	client.Policy.Backoff = noBackoff
+	client.Hooks = []Hooks{LogHooks(logger)}
+	res, err := client.Get(context.Background(), server.URL+"/files/123456?apikey=secret-key")
+	assert.Nil(err)
+	ReportAPIError(res, "not_found")
+
+	assert.Equal([]string{
+		"DEBUG threeplay: request method=GET endpoint=/files/123456 attempt=1",
+		"WARN threeplay: response method=GET endpoint=/files/123456 attempt=1 status=500",
+		"WARN threeplay: retrying method=GET endpoint=/files/123456 attempt=1 wait=0s status=500",
+		"DEBUG threeplay: request method=GET endpoint=/files/123456 attempt=2",
+		"DEBUG threeplay: response method=GET endpoint=/files/123456 attempt=2 status=404",
+		"WARN threeplay: api error method=GET endpoint=/files/123456 attempt=2 status=404 type=not_found",
+	}, logger.lines)
+}
+
+func TestLogHooksErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL + "/files/1?apikey=secret-key"
+	server.Close()
+
+	logger := &testLogger{}
+	client := New(http.DefaultClient)
+	client.Policy.MaxAttempts = 1
+	client.Hooks = []Hooks{LogHooks(logger)}
+	_, err := client.Get(context.Background(), endpoint)
+	assert.NotNil(t, err)
+	assert.Len(t, logger.lines, 2)
+	assert.Contains(t, logger.lines[1], "WARN threeplay: request failed method=GET endpoint=/files/1 attempt=1 error=")
+	assert.NotContains(t, logger.lines[1], "secret-key")
+}
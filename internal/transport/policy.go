@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sethgrid/pester"
+)
+
+// DefaultMaxRetryAfter caps the Retry-After waits honored by default
+const DefaultMaxRetryAfter = time.Minute
+
+// Policy decides which failed attempts are retried and how long to wait
+// before the next one. The zero value uses the defaults.
+type Policy struct {
+	// MaxAttempts is the number of attempts made for a call, the first one
+	// included. Defaults to DefaultMaxRetries; 1 disables retries.
+	MaxAttempts int
+	// Backoff is the wait before the given retry, when the server doesn't
+	// say. Defaults to pester.ExponentialJitterBackoff.
+	Backoff pester.BackoffStrategy
+	// MaxRetryAfter caps the Retry-After waits. Responses asking for longer
+	// are returned to the caller. Defaults to DefaultMaxRetryAfter.
+	MaxRetryAfter time.Duration
+	// RetryNonIdempotent retries non-idempotent requests after network
+	// errors and 5xx responses, when the server may have processed them.
+	// It's off by default since it may, for example, duplicate orders.
+	RetryNonIdempotent bool
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxRetries
+	}
+	if p.Backoff == nil {
+		p.Backoff = pester.ExponentialJitterBackoff
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = DefaultMaxRetryAfter
+	}
+	return p
+}
+
+// retry decides whether a failed attempt is retried, and after how long.
+// 429 responses and requests that never reached the server are always safe
+// to retry; other failures only are for idempotent requests.
+func (p Policy) retry(req *http.Request, attempt int, res *http.Response, err error) (time.Duration, bool) {
+	idempotent := p.RetryNonIdempotent || isIdempotent(req)
+	if err != nil {
+		return p.Backoff(attempt), idempotent || NotSent(err)
+	}
+	if res.StatusCode != http.StatusTooManyRequests && (!retryableStatus(res.StatusCode) || !idempotent) {
+		return 0, false
+	}
+	if wait, ok := retryAfter(res, time.Now()); ok {
+		return wait, wait <= p.MaxRetryAfter
+	}
+	return p.Backoff(attempt), true
+}
+
+func retryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError && code != http.StatusNotImplemented
+}
+
+type idempotentKey struct{}
+
+// MarkIdempotent marks the requests made with ctx as idempotent, for POSTs
+// that are safe to send twice, such as cancellations
+func MarkIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent reports whether sending the request twice has the same effect
+// as sending it once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if marked, _ := req.Context().Value(idempotentKey{}).(bool); marked {
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// NotSent reports whether the request failed before reaching the server,
+// when the connection couldn't be established
+func NotSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an HTTP date
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Attempts is the number of attempts made for a call
+func (p Policy) Attempts() int {
+	return p.withDefaults().MaxAttempts
+}
+
+// Wait sleeps for the backoff before the given retry, or until ctx is done.
+// It's meant for callers retrying on their own, such as orders.
+func (p Policy) Wait(ctx context.Context, attempt int) error {
+	return sleep(ctx, p.withDefaults().Backoff(attempt))
+}
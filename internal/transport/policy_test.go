@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingServer responds with the given statuses in turn, then with 200
+func countingServer(calls *int32, header http.Header, statuses ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(calls, 1))
+		if n <= len(statuses) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statuses[n-1])
+		}
+	}))
+}
+
+func TestDoDoesNotRetryNonIdempotentServerErrors(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := countingServer(&calls, nil, http.StatusInternalServerError)
+	defer server.Close()
+
+	client := New(server.Client())
+	client.Policy.Backoff = noBackoff
+	res, err := client.PostForm(context.Background(), server.URL, url.Values{})
+	assert.Nil(err)
+	assert.Equal(http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+
+	client.Policy.RetryNonIdempotent = true
+	atomic.StoreInt32(&calls, 0)
+	res, err = client.PostForm(context.Background(), server.URL, url.Values{})
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDoRetriesTooManyRequests(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := countingServer(&calls, http.Header{"Retry-After": {"0"}}, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	defer server.Close()
+
+	client := New(server.Client())
+	client.Policy.Backoff = func(int) time.Duration { return time.Hour }
+	res, err := client.PostForm(context.Background(), server.URL, url.Values{})
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDoGivesUpOnLongRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := countingServer(&calls, http.Header{"Retry-After": {"120"}}, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	client := New(server.Client())
+	res, err := client.Get(context.Background(), server.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+
+	client.Policy.MaxRetryAfter = 5 * time.Minute
+	atomic.StoreInt32(&calls, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, server.URL)
+	assert.Equal(context.DeadlineExceeded, err)
+}
+
+func TestDoRetriesUnsentNonIdempotentRequests(t *testing.T) {
+	assert := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err)
+	endpoint := "http://" + listener.Addr().String()
+	listener.Close()
+
+	var attempts []int
+	client := New(&http.Client{})
+	client.Policy.MaxAttempts = 3
+	client.Policy.Backoff = func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return 0
+	}
+	_, err = client.PostForm(context.Background(), endpoint, url.Values{})
+	assert.NotNil(err)
+	assert.Equal([]int{1, 2}, attempts)
+}
+
+func TestDoHonorsIdempotencyMarkers(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := countingServer(&calls, nil, http.StatusBadGateway)
+	defer server.Close()
+	client := New(server.Client())
+	client.Policy.Backoff = noBackoff
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	req.Header.Set("Idempotency-Key", "order-1")
+	res, err := client.Do(req)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	parse := func(value string) (time.Duration, bool) {
+		return retryAfter(&http.Response{Header: http.Header{"Retry-After": {value}}}, now)
+	}
+
+	wait, ok := parse("30")
+	assert.True(ok)
+	assert.Equal(30*time.Second, wait)
+	wait, ok = parse("Wed, 01 Jan 2020 12:00:10 GMT")
+	assert.True(ok)
+	assert.Equal(10*time.Second, wait)
+	wait, ok = parse("Wed, 01 Jan 2020 11:00:00 GMT")
+	assert.True(ok)
+	assert.Equal(time.Duration(0), wait)
+	_, ok = parse("soon")
+	assert.False(ok)
+	_, ok = retryAfter(&http.Response{Header: http.Header{}}, now)
+	assert.False(ok)
+}
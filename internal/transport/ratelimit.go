@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nytimes/threeplay/ratelimit"
+)
+
+type staticKey struct{}
+
+// MarkStatic marks the requests made with ctx as downloads from the static
+// host, which have their own rate limit budget
+func MarkStatic(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staticKey{}, true)
+}
+
+type apiKeyKey struct{}
+
+// WithAPIKey records the API key of the requests made with ctx, for requests
+// whose body carries the key but can't be read twice, such as multipart
+// uploads
+func WithAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, apiKey)
+}
+
+// limitHost returns the host whose budget the request takes from
+func limitHost(req *http.Request) ratelimit.Host {
+	if static, _ := req.Context().Value(staticKey{}).(bool); static {
+		return ratelimit.Static
+	}
+	return ratelimit.API
+}
+
+// requestAPIKey returns the API key the request is sent with, looking at the
+// context, then the query string and finally url-encoded bodies
+func requestAPIKey(req *http.Request) string {
+	if apiKey, ok := req.Context().Value(apiKeyKey{}).(string); ok {
+		return apiKey
+	}
+	if apiKey := credential(req.URL.Query()); apiKey != "" {
+		return apiKey
+	}
+	if req.GetBody == nil || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	form, _ := url.ParseQuery(string(data))
+	return credential(form)
+}
+
+// credential returns the API key of either API version
+func credential(values url.Values) string {
+	if apiKey := values.Get("api_key"); apiKey != "" {
+		return apiKey
+	}
+	return values.Get("apikey")
+}
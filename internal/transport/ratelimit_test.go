@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestAPIKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	get, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.3playmedia.com/v3/files?api_key=v3-key", nil)
+	assert.Equal("v3-key", requestAPIKey(get))
+
+	get, _ = http.NewRequestWithContext(ctx, http.MethodGet, "https://static.3playmedia.com/files/1/captions.srt?apikey=v2-key", nil)
+	assert.Equal("v2-key", requestAPIKey(get))
+
+	form := url.Values{"api_key": {"form-key"}}
+	post, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.3playmedia.com/v3/files", strings.NewReader(form.Encode()))
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal("form-key", requestAPIKey(post))
+
+	upload, _ := http.NewRequestWithContext(WithAPIKey(ctx, "upload-key"), http.MethodPost, "https://api.3playmedia.com/v3/files", strings.NewReader("--boundary"))
+	upload.Header.Set("Content-Type", "multipart/form-data; boundary=boundary")
+	assert.Equal("upload-key", requestAPIKey(upload))
+}
+
+func TestLimitHost(t *testing.T) {
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://static.3playmedia.com/", nil)
+	assert.Equal(t, ratelimit.API, limitHost(req))
+	assert.Equal(t, ratelimit.Static, limitHost(req.WithContext(MarkStatic(req.Context()))))
+}
+
+func TestDoWaitsForLimiter(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	rec := &recorder{}
+	client := New(server.Client())
+	// a frozen clock makes the wait independent of how long requests take
+	frozen := time.Now()
+	client.Limiter = ratelimit.New(ratelimit.Limit{Rate: 50, Burst: 1}, ratelimit.WithClock(func() time.Time { return frozen }))
+	client.Hooks = []Hooks{rec}
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), server.URL+"?api_key=key")
+		assert.Nil(err)
+	}
+	assert.Equal(time.Duration(0), rec.events[0].event.LimitWait)
+	assert.Equal(20*time.Millisecond, rec.events[2].event.LimitWait)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.Limiter = ratelimit.New(ratelimit.Limit{Rate: 0.001, Burst: 1})
+	_, err := client.Get(ctx, server.URL+"?api_key=key")
+	assert.Nil(err)
+	res, err := client.Get(ctx, server.URL+"?api_key=key")
+	assert.Nil(res)
+	assert.Equal(context.DeadlineExceeded, err)
+}
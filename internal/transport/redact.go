@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+)
+
+// Redacted replaces the value of credential parameters
+const Redacted = "REDACTED"
+
+// credentials matches the query and form parameters carrying the API key and
+// secret of either API version
+var credentials = regexp.MustCompile(`(?i)\b(apikey|api_key|api_secret_key|api_secret)=[^&\s"'#]*`)
+
+// Redact masks the credentials in s, a URL, an encoded form or a message
+// quoting one.
+func Redact(s string) string {
+	return credentials.ReplaceAllString(s, "${1}="+Redacted)
+}
+
+// RedactError returns err with the credentials masked in the URL it carries.
+// The http package reports failed requests as *url.Error quoting the full
+// URL, query string included; other errors are returned unchanged.
+func RedactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := *urlErr
+	redacted.URL = Redact(urlErr.URL)
+	if msg := urlErr.Err.Error(); Redact(msg) != msg {
+		redacted.Err = &redactedError{err: urlErr.Err}
+	}
+	if urlErr == err {
+		return &redacted
+	}
+	return &redactedError{err: err, urlErr: &redacted}
+}
+
+// redactedError masks the credentials in the message of an error that
+// wraps a *url.Error or quotes a URL itself
+type redactedError struct {
+	err    error
+	urlErr *url.Error
+}
+
+func (e *redactedError) Error() string {
+	return Redact(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	if e.urlErr != nil {
+		return e.urlErr
+	}
+	return errors.Unwrap(e.err)
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"https://api.3playmedia.com/v3/files?api_key=secret-key&page=2": "https://api.3playmedia.com/v3/files?api_key=REDACTED&page=2",
+		"apikey=key&api_secret_key=secret&name=tag":                     "apikey=REDACTED&api_secret_key=REDACTED&name=tag",
+		`Get "https://3playmedia.com/files/1?APIKEY=key": EOF`:          `Get "https://3playmedia.com/files/1?APIKEY=REDACTED": EOF`,
+		"https://api.3playmedia.com/files?x_apikey=kept":                "https://api.3playmedia.com/files?x_apikey=kept",
+		"no credentials here":                                           "no credentials here",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, Redact(in), in)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	assert := assert.New(t)
+	inner := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	err := RedactError(&url.Error{Op: "Get", URL: "https://api.3playmedia.com/files/1?apikey=secret-key", Err: inner})
+
+	assert.NotContains(err.Error(), "secret-key")
+	var urlErr *url.Error
+	assert.True(errors.As(err, &urlErr))
+	assert.Equal("https://api.3playmedia.com/files/1?apikey=REDACTED", urlErr.URL)
+	assert.True(errors.Is(err, inner))
+
+	wrapped := RedactError(&wrapError{&url.Error{Op: "Get", URL: "https://api.3playmedia.com/?api_key=secret-key", Err: inner}})
+	assert.NotContains(wrapped.Error(), "secret-key")
+	assert.True(errors.As(wrapped, &urlErr))
+	assert.NotContains(urlErr.URL, "secret-key")
+
+	plain := errors.New("plain")
+	assert.Equal(plain, RedactError(plain))
+	assert.Nil(RedactError(nil))
+}
+
+func TestDoRedactsErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL + "/v3/files?api_key=secret-key"
+	server.Close()
+
+	client := New(http.DefaultClient)
+	client.Policy.MaxAttempts = 1
+	res, err := client.Get(context.Background(), endpoint)
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+	assert.False(t, strings.Contains(err.Error(), "secret-key"), err.Error())
+}
+
+type wrapError struct{ err error }
+
+func (e *wrapError) Error() string { return "wrapped: " + e.err.Error() }
+func (e *wrapError) Unwrap() error { return e.err }
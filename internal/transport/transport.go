@@ -0,0 +1,135 @@
+// Package transport implements the HTTP round trip shared by the v2api and
+// v3api clients.
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nytimes/threeplay/ratelimit"
+)
+
+// DefaultMaxRetries is the number of attempts made for a single call
+const DefaultMaxRetries = 5
+
+// Client sends requests to 3Play, retrying failures as its Policy allows.
+// Unlike pester, every attempt and every backoff wait honors the request
+// context, so a cancelled caller never keeps retrying in the background.
+type Client struct {
+	HTTPClient *http.Client
+	Policy     Policy
+	Hooks      []Hooks
+	Limiter    *ratelimit.Limiter
+}
+
+// New returns a Client wrapping the given http client
+func New(client *http.Client) *Client {
+	return &Client{HTTPClient: client}
+}
+
+// Get issues a GET request to the given URL
+func (c *Client) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, RedactError(err)
+	}
+	return c.Do(req)
+}
+
+// PostForm issues a POST request with data url-encoded in the body
+func (c *Client) PostForm(ctx context.Context, endpoint string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, RedactError(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.Do(req)
+}
+
+// Do sends the request, retrying as the policy allows. Every attempt first
+// waits for the rate limiter, when the client has one. Requests whose body
+// cannot be rewound (no GetBody) are sent only once. When the last attempt
+// still fails with a 429 or 5xx status the response is returned so the
+// caller can decode the error payload. Errors never quote the credentials
+// carried by the request URL.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var current *call
+	if len(c.Hooks) > 0 {
+		current = &call{client: c}
+		req = req.WithContext(context.WithValue(ctx, callKey{}, current))
+	}
+	policy := c.Policy.withDefaults()
+	attempts := policy.MaxAttempts
+	if req.Body != nil && req.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		event := newEvent(req, attempt)
+		if c.Limiter != nil {
+			wait, err := c.Limiter.Wait(ctx, limitHost(req), requestAPIKey(req))
+			if err != nil {
+				return nil, err
+			}
+			event.LimitWait = wait
+		}
+		c.notify(ctx, event, Hooks.OnRequest)
+		start := time.Now()
+		res, err := c.HTTPClient.Do(req)
+		event.Duration = time.Since(start)
+		if err != nil {
+			event.Err = RedactError(err)
+			c.notify(ctx, event, Hooks.OnError)
+		} else {
+			event.StatusCode = res.StatusCode
+			if current != nil {
+				current.event = event
+			}
+			c.notify(ctx, event, Hooks.OnResponse)
+		}
+		if err == nil && res.StatusCode != http.StatusTooManyRequests && !retryableStatus(res.StatusCode) {
+			return res, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			CloseBody(res)
+			return nil, ctxErr
+		}
+		if attempt >= attempts {
+			return res, RedactError(err)
+		}
+		wait, retry := policy.retry(req, attempt, res, err)
+		if !retry {
+			return res, RedactError(err)
+		}
+		CloseBody(res)
+		event.Wait = wait
+		c.notify(ctx, event, Hooks.OnRetry)
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+func TestDoRetriesServerErrors(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal("name=value", string(body))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := New(server.Client())
+	client.Policy.Backoff = noBackoff
+	res, err := client.PostForm(MarkIdempotent(context.Background()), server.URL, url.Values{"name": {"value"}})
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDoReturnsLastServerError(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := New(server.Client())
+	client.Policy.Backoff = noBackoff
+	res, err := client.Get(context.Background(), server.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusBadGateway, res.StatusCode)
+	assert.Equal(int32(DefaultMaxRetries), atomic.LoadInt32(&calls))
+}
+
+func TestDoStopsOnCancelDuringBackoff(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := New(server.Client())
+	client.Policy.Backoff = func(int) time.Duration {
+		cancel()
+		return time.Hour
+	}
+
+	start := time.Now()
+	res, err := client.Get(ctx, server.URL)
+	assert.Nil(res)
+	assert.Equal(context.Canceled, err)
+	assert.True(time.Since(start) < time.Second)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDoDoesNotStartWhenCancelled(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the server")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := New(server.Client()).Get(ctx, server.URL)
+	assert.Nil(res)
+	assert.Equal(context.Canceled, err)
+}
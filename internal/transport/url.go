@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseBaseURL validates a base URL given through a client option
+func ParseBaseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %v", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", rawURL)
+	}
+	return u, nil
+}
+
+// BuildURL joins endpoint to the base URL path and sets the encoded query.
+// Any path the base URL carries is kept as a prefix, which allows pointing
+// the clients at a stand-in mounted below the server root.
+func BuildURL(base *url.URL, endpoint string, query url.Values) string {
+	u := *base
+	u.Path = strings.TrimSuffix(u.Path, "/") + endpoint
+	u.RawPath = ""
+	u.RawQuery = ""
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
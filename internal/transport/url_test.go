@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBaseURL(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{"https host", "https://api.3playmedia.com", true},
+		{"with path prefix", "http://127.0.0.1:8080/threeplay", true},
+		{"missing scheme", "api.3playmedia.com", false},
+		{"missing host", "https://", false},
+		{"unparseable", "://bad", false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			u, err := ParseBaseURL(test.input)
+			if test.valid {
+				assert.Nil(t, err)
+				assert.NotNil(t, u)
+			} else {
+				assert.NotNil(t, err)
+				assert.Nil(t, u)
+			}
+		})
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		base     string
+		endpoint string
+		query    url.Values
+		expected string
+	}{
+		{
+			"no query",
+			"https://api.3playmedia.com",
+			"/files/123",
+			nil,
+			"https://api.3playmedia.com/files/123",
+		},
+		{
+			"with query",
+			"https://static.3playmedia.com",
+			"/files/123/captions.srt",
+			url.Values{"apikey": {"key"}, "usevideoid": {"1"}},
+			"https://static.3playmedia.com/files/123/captions.srt?apikey=key&usevideoid=1",
+		},
+		{
+			"base with path prefix",
+			"http://127.0.0.1:8080/stub/",
+			"/v3/transcripts/1",
+			url.Values{"api_key": {"key"}},
+			"http://127.0.0.1:8080/stub/v3/transcripts/1?api_key=key",
+		},
+		{
+			"escapes path segments",
+			"https://api.3playmedia.com",
+			"/files/1/tags/a tag",
+			nil,
+			"https://api.3playmedia.com/files/1/tags/a%20tag",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			base, err := ParseBaseURL(test.base)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, BuildURL(base, test.endpoint, test.query))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileStore keeps each job in a JSON file of a directory. Files are replaced
+// atomically, so a crash mid-write leaves the previous state of the job.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore returns a FileStore in dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// maxHexID is the longest ID whose job file is named after it: longer IDs
+// would exceed the file name limits of common file systems
+const maxHexID = 100
+
+// path returns the file of a job. IDs are hex encoded so that any ID makes a
+// valid file name, and longer IDs are hashed. The ID itself is kept in the
+// file.
+func (s *FileStore) path(id string) string {
+	if len(id) > maxHexID {
+		sum := sha256.Sum256([]byte(id))
+		return filepath.Join(s.dir, "sha256-"+hex.EncodeToString(sum[:])+".json")
+	}
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(id))+".json")
+}
+
+// Create saves a new job
+func (s *FileStore) Create(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(s.path(job.ID)); err == nil {
+		return ErrExists
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return s.write(job)
+}
+
+// Get reads a job
+func (s *FileStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(id))
+}
+
+// Update replaces a saved job
+func (s *FileStore) Update(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(s.path(job.ID)); os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return s.write(job)
+}
+
+// List reads every job, by creation time
+func (s *FileStore) List(ctx context.Context) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*Job
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		job, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+func (s *FileStore) read(path string) (*Job, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// write saves a job to a temporary file and renames it over the job file
+func (s *FileStore) write(job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(s.dir, ".job-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(job.ID))
+}
@@ -0,0 +1,94 @@
+// Package jobs runs captioning jobs (upload, order, wait, download and
+// publish) as state machines persisted in a Store, so that they can be
+// resumed after a crash without uploading or ordering twice.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// State is the step a job is at
+type State string
+
+// Job states, in order. StateDone and StateFailed are terminal.
+const (
+	// StatePending jobs haven't uploaded their media yet
+	StatePending State = "pending"
+	// StateUploaded jobs have a media file but no transcript order
+	StateUploaded State = "uploaded"
+	// StateOrdered jobs wait for their transcript
+	StateOrdered State = "ordered"
+	// StateTranscribed jobs have a complete transcript to download
+	StateTranscribed State = "transcribed"
+	// StateDownloaded jobs have their captions, ready to be published
+	StateDownloaded State = "downloaded"
+	StateDone       State = "done"
+	StateFailed     State = "failed"
+)
+
+// Terminal reports whether a job in the state is finished
+func (s State) Terminal() bool {
+	return s == StateDone || s == StateFailed
+}
+
+var (
+	// ErrNotFound is returned by stores for unknown job IDs
+	ErrNotFound = errors.New("job not found")
+	// ErrExists is returned by Store.Create for IDs already in use
+	ErrExists = errors.New("job already exists")
+)
+
+// Job is a captioning job. The fields set by callers are ID, SourceURL,
+// Name, Turnaround, CallbackURL and Formats; the others track its progress.
+type Job struct {
+	// ID identifies the job. It's sent as the reference ID of the media file,
+	// which is how an upload interrupted by a crash is found again.
+	ID        string `json:"id"`
+	SourceURL string `json:"source_url"`
+	Name      string `json:"name,omitempty"`
+	// Turnaround is "asr" or a turnaround level ID. Defaults to "asr".
+	Turnaround  string `json:"turnaround,omitempty"`
+	CallbackURL string `json:"callback_url,omitempty"`
+	// Formats are the captions formats to download. Defaults to SRT.
+	Formats []types.CaptionsFormat `json:"formats,omitempty"`
+
+	State        State                           `json:"state"`
+	MediaFileID  string                          `json:"media_file_id,omitempty"`
+	TranscriptID int                             `json:"transcript_id,omitempty"`
+	Captions     map[types.CaptionsFormat]string `json:"captions,omitempty"`
+	// Attempts counts the failed attempts at the current step
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// clone returns a deep copy of the job, so that stores and callers don't
+// share maps and slices
+func (j *Job) clone() *Job {
+	c := *j
+	c.Formats = append([]types.CaptionsFormat(nil), j.Formats...)
+	if j.Captions != nil {
+		c.Captions = make(map[types.CaptionsFormat]string, len(j.Captions))
+		for format, text := range j.Captions {
+			c.Captions[format] = text
+		}
+	}
+	return &c
+}
+
+// Store persists jobs. Implementations must be safe for concurrent use.
+type Store interface {
+	// Create saves a new job, or returns ErrExists
+	Create(ctx context.Context, job *Job) error
+	// Get returns a job, or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+	// Update replaces a saved job, or returns ErrNotFound
+	Update(ctx context.Context, job *Job) error
+	// List returns every job
+	List(ctx context.Context) ([]*Job, error)
+}
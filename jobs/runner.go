@@ -0,0 +1,359 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// Defaults of the Runner options
+const (
+	DefaultConcurrency = 4
+	DefaultMaxAttempts = 5
+)
+
+// Publisher publishes the captions of a downloaded job. It may be called
+// again for the same job after a crash, so it should be idempotent.
+type Publisher func(ctx context.Context, job *Job) error
+
+// TransitionHook is called after a job moved from one state to another and
+// the new state was saved
+type TransitionHook func(ctx context.Context, job *Job, from State)
+
+// Runner moves jobs through their states with a v3 client. Only one Runner
+// should work on a Store at a time.
+type Runner struct {
+	client      *v3api.Client
+	store       Store
+	concurrency int
+	maxAttempts int
+	publish     Publisher
+	hooks       []TransitionHook
+	wait        v3api.WaitOptions
+	now         func() time.Time
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// Option configures optional behavior of a Runner
+type Option func(*Runner)
+
+// WithConcurrency sets how many jobs Resume runs at once. It defaults to
+// DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.concurrency = n
+		}
+	}
+}
+
+// WithMaxAttempts sets how many times a step is tried before the job fails.
+// It defaults to DefaultMaxAttempts.
+func WithMaxAttempts(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.maxAttempts = n
+		}
+	}
+}
+
+// WithPublisher sets the last step of the jobs. Without one, jobs are done
+// once their captions are downloaded.
+func WithPublisher(publish Publisher) Option {
+	return func(r *Runner) {
+		r.publish = publish
+	}
+}
+
+// WithTransitionHook adds a hook called on every state transition
+func WithTransitionHook(hook TransitionHook) Option {
+	return func(r *Runner) {
+		r.hooks = append(r.hooks, hook)
+	}
+}
+
+// WithWaitOptions sets how transcripts are polled
+func WithWaitOptions(opts v3api.WaitOptions) Option {
+	return func(r *Runner) {
+		r.wait = opts
+	}
+}
+
+// WithClock sets the function used to timestamp jobs
+func WithClock(now func() time.Time) Option {
+	return func(r *Runner) {
+		r.now = now
+	}
+}
+
+// NewRunner returns a Runner working on the jobs of store
+func NewRunner(client *v3api.Client, store Store, opts ...Option) *Runner {
+	r := &Runner{
+		client:      client,
+		store:       store,
+		concurrency: DefaultConcurrency,
+		maxAttempts: DefaultMaxAttempts,
+		now:         time.Now,
+		running:     map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Submit saves a new job in StatePending. It doesn't run it.
+func (r *Runner) Submit(ctx context.Context, job *Job) error {
+	if job.ID == "" || job.SourceURL == "" {
+		return errors.New("jobs: ID and SourceURL are required")
+	}
+	job = job.clone()
+	if job.Turnaround == "" {
+		job.Turnaround = "asr"
+	}
+	if len(job.Formats) == 0 {
+		job.Formats = []types.CaptionsFormat{types.SRT}
+	}
+	job.State = StatePending
+	job.CreatedAt = r.now()
+	job.UpdatedAt = job.CreatedAt
+	return r.store.Create(ctx, job)
+}
+
+// Run moves a job through its states until it's done, it fails or ctx is
+// done. Steps that fail are retried on the next Run, up to the max attempts.
+func (r *Runner) Run(ctx context.Context, id string) (*Job, error) {
+	r.mu.Lock()
+	if r.running[id] {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("jobs: job %s is already running", id)
+	}
+	r.running[id] = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, id)
+		r.mu.Unlock()
+	}()
+
+	job, err := r.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for !job.State.Terminal() {
+		from := job.State
+		if err := r.step(ctx, job); err != nil {
+			return job, r.fail(ctx, job, err)
+		}
+		if err := r.transition(ctx, job, from); err != nil {
+			return job, err
+		}
+	}
+	return job, nil
+}
+
+// Resume runs every job that isn't finished, a few at a time. It returns the
+// first error of the jobs, which are all run regardless.
+func (r *Runner) Resume(ctx context.Context) error {
+	jobs, err := r.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		slots    = make(chan struct{}, r.concurrency)
+	)
+	for _, job := range jobs {
+		if job.State.Terminal() {
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer func() { <-slots; wg.Done() }()
+			if _, err := r.Run(ctx, id); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("jobs: job %s: %w", id, err)
+				}
+				mu.Unlock()
+			}
+		}(job.ID)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// step runs the current step of the job, updating it in place
+func (r *Runner) step(ctx context.Context, job *Job) error {
+	switch job.State {
+	case StatePending:
+		return r.upload(ctx, job)
+	case StateUploaded:
+		return r.order(ctx, job)
+	case StateOrdered:
+		return r.waitTranscript(ctx, job)
+	case StateTranscribed:
+		return r.download(ctx, job)
+	case StateDownloaded:
+		if r.publish != nil {
+			if err := r.publish(ctx, job); err != nil {
+				return err
+			}
+		}
+		job.State = StateDone
+		return nil
+	}
+	return fmt.Errorf("jobs: unknown state %q", job.State)
+}
+
+// upload uploads the media, unless a previous attempt already did. Uploads
+// are tagged with the job ID as reference ID to find them again. The files
+// listed are checked too, in case the filter matched loosely.
+func (r *Runner) upload(ctx context.Context, job *Job) error {
+	page, err := r.client.ListFilesContext(ctx, v3api.ListFilesOptions{
+		Filters: url.Values{"reference_id": {job.ID}},
+	}, v3api.CallParams{})
+	if err != nil {
+		return err
+	}
+	for _, file := range page.Files {
+		if file.ReferenceID == job.ID {
+			job.MediaFileID = strconv.Itoa(file.ID)
+			job.State = StateUploaded
+			return nil
+		}
+	}
+
+	options := url.Values{"source_url": {job.SourceURL}, "reference_id": {job.ID}}
+	if job.Name != "" {
+		options.Set("name", job.Name)
+	}
+	id, err := r.client.UploadFileFromURLContext(ctx, options, v3api.CallParams{})
+	if err != nil {
+		return err
+	}
+	job.MediaFileID = strconv.Itoa(id)
+	job.State = StateUploaded
+	return nil
+}
+
+// order orders the transcript, unless a previous attempt already did
+func (r *Runner) order(ctx context.Context, job *Job) error {
+	transcript, err := r.client.GetTranscriptInfoContext(ctx, job.MediaFileID, v3api.CallParams{})
+	if err != nil && !errors.Is(err, v3api.ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		transcript, err = r.client.OrderTranscriptContext(ctx, job.MediaFileID, job.CallbackURL, job.Turnaround, v3api.CallParams{})
+		if err != nil {
+			return err
+		}
+	}
+	job.TranscriptID = transcript.ID
+	job.State = StateOrdered
+	return nil
+}
+
+func (r *Runner) waitTranscript(ctx context.Context, job *Job) error {
+	if _, err := r.client.WaitForTranscript(ctx, job.MediaFileID, r.wait); err != nil {
+		return err
+	}
+	job.State = StateTranscribed
+	return nil
+}
+
+func (r *Runner) download(ctx context.Context, job *Job) error {
+	captions := map[types.CaptionsFormat]string{}
+	for _, format := range job.Formats {
+		text, err := r.client.GetTranscriptTextContext(ctx, job.MediaFileID, "", format, v3api.CallParams{})
+		if err != nil {
+			return err
+		}
+		captions[format] = text
+	}
+	job.Captions = captions
+	job.State = StateDownloaded
+	return nil
+}
+
+// transition saves the job in its new state and calls the hooks
+func (r *Runner) transition(ctx context.Context, job *Job, from State) error {
+	job.Attempts = 0
+	job.LastError = ""
+	job.UpdatedAt = r.now()
+	if err := r.store.Update(ctx, job); err != nil {
+		return err
+	}
+	for _, hook := range r.hooks {
+		hook(ctx, job.clone(), from)
+	}
+	return nil
+}
+
+// fail records a failed step. The job fails for good on permanent errors and
+// once it runs out of attempts; context errors aren't counted.
+func (r *Runner) fail(ctx context.Context, job *Job, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	from := job.State
+	job.Attempts++
+	job.LastError = err.Error()
+	job.UpdatedAt = r.now()
+	if !permanent(err) && job.Attempts < r.maxAttempts {
+		if saveErr := r.store.Update(ctx, job); saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+	job.State = StateFailed
+	if saveErr := r.store.Update(ctx, job); saveErr != nil {
+		return saveErr
+	}
+	for _, hook := range r.hooks {
+		hook(ctx, job.clone(), from)
+	}
+	return err
+}
+
+// permanent reports whether retrying a step can't help: the transcript was
+// cancelled or failed, or the API rejected the request itself, whether
+// through the HTTP status or the code of the payload
+func permanent(err error) bool {
+	if errors.Is(err, v3api.ErrTranscriptCancelled) || errors.Is(err, v3api.ErrTranscriptFailed) ||
+		errors.Is(err, v3api.ErrUnsupportedFormat) {
+		return true
+	}
+	if errors.Is(err, v3api.ErrUnauthorized) || errors.Is(err, v3api.ErrNotFound) {
+		return true
+	}
+	var apiErr *v3api.APIError
+	if errors.As(err, &apiErr) {
+		// errors reported in the payload of a 2xx response carry their code
+		// there
+		code := apiErr.StatusCode
+		if code < 400 {
+			code = apiErr.Code
+		}
+		return code >= 400 && code < 500 && code != http.StatusTooManyRequests
+	}
+	return false
+}
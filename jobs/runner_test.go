@@ -0,0 +1,275 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/jobs"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+// completeOnOrder completes transcripts on the fake server as soon as they're
+// ordered
+func completeOnOrder(server *threeplaytest.Server) jobs.Option {
+	return jobs.WithTransitionHook(func(ctx context.Context, job *jobs.Job, from jobs.State) {
+		if job.State == jobs.StateOrdered {
+			id, _ := strconv.Atoi(job.MediaFileID)
+			server.CompleteTranscript(id)
+		}
+	})
+}
+
+var fastWait = jobs.WithWaitOptions(v3api.WaitOptions{Interval: time.Millisecond})
+
+func newJob(id string) *jobs.Job {
+	return &jobs.Job{
+		ID:        id,
+		SourceURL: "https://somewhere.com/" + id + ".mp4",
+		Formats:   []types.CaptionsFormat{types.SRT, types.WebVTT},
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	var (
+		transitions []string
+		published   []string
+	)
+	runner := jobs.NewRunner(client, jobs.NewMemoryStore(), completeOnOrder(server), fastWait,
+		jobs.WithTransitionHook(func(ctx context.Context, job *jobs.Job, from jobs.State) {
+			transitions = append(transitions, fmt.Sprintf("%s->%s", from, job.State))
+		}),
+		jobs.WithPublisher(func(ctx context.Context, job *jobs.Job) error {
+			published = append(published, job.ID)
+			return nil
+		}),
+	)
+
+	assert.Nil(runner.Submit(context.Background(), newJob("speech")))
+	job, err := runner.Run(context.Background(), "speech")
+	assert.Nil(err)
+	assert.Equal(jobs.StateDone, job.State)
+	assert.Equal([]string{
+		"pending->uploaded", "uploaded->ordered", "ordered->transcribed",
+		"transcribed->downloaded", "downloaded->done",
+	}, transitions)
+	assert.Equal([]string{"speech"}, published)
+	assert.Contains(job.Captions[types.SRT], "-->")
+	assert.Contains(job.Captions[types.WebVTT], "WEBVTT")
+	assert.NotZero(job.TranscriptID)
+
+	files, err := client.ListFiles(v3api.ListFilesOptions{Filters: url.Values{"reference_id": {"speech"}}}, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Len(files.Files, 1)
+}
+
+func TestRunDoesNotReuploadAfterCrash(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	runner := jobs.NewRunner(client, jobs.NewMemoryStore(), completeOnOrder(server), fastWait)
+	assert.Nil(runner.Submit(context.Background(), newJob("speech")))
+
+	// the worker uploaded, then crashed before saving the job
+	uploaded, err := client.UploadFileFromURL(url.Values{
+		"source_url":   {"https://somewhere.com/speech.mp4"},
+		"reference_id": {"speech"},
+	}, v3api.CallParams{})
+	assert.Nil(err)
+
+	job, err := runner.Run(context.Background(), "speech")
+	assert.Nil(err)
+	assert.Equal(jobs.StateDone, job.State)
+	assert.Equal(strconv.Itoa(uploaded), job.MediaFileID)
+	files, err := client.ListFiles(v3api.ListFilesOptions{}, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal(1, files.TotalEntries)
+}
+
+func TestRunIgnoresFilesOfOtherJobs(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	// a proxy dropping the reference_id filter, as a loose API would
+	target, _ := url.Parse(server.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		query := r.URL.Query()
+		query.Del("reference_id")
+		r.URL.RawQuery = query.Encode()
+	}
+	loose := httptest.NewServer(proxy)
+	defer loose.Close()
+
+	client := v3api.NewClient(server.APIKey, v3api.WithBaseURL(loose.URL))
+	other, err := client.UploadFileFromURL(url.Values{
+		"source_url":   {"https://somewhere.com/other.mp4"},
+		"reference_id": {"other"},
+	}, v3api.CallParams{})
+	assert.Nil(err)
+
+	runner := jobs.NewRunner(client, jobs.NewMemoryStore(), completeOnOrder(server), fastWait)
+	assert.Nil(runner.Submit(context.Background(), newJob("speech")))
+	job, err := runner.Run(context.Background(), "speech")
+	assert.Nil(err)
+	assert.Equal(jobs.StateDone, job.State)
+	assert.NotEqual(strconv.Itoa(other), job.MediaFileID)
+	file, err := client.GetFile(job.MediaFileID, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal("speech", file.ReferenceID)
+}
+
+func TestRunDoesNotReorderAfterCrash(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	store := jobs.NewMemoryStore()
+	runner := jobs.NewRunner(client, store, fastWait)
+
+	// the worker ordered, then crashed before saving the job
+	fileID := server.AddFile("speech.mp4")
+	mediaFileID := strconv.Itoa(fileID)
+	ordered, err := client.OrderTranscript(mediaFileID, "", "asr", v3api.CallParams{})
+	assert.Nil(err)
+	assert.Nil(server.CompleteTranscript(fileID))
+	job := newJob("speech")
+	job.State = jobs.StateUploaded
+	job.MediaFileID = mediaFileID
+	assert.Nil(store.Create(context.Background(), job))
+
+	job, err = runner.Run(context.Background(), "speech")
+	assert.Nil(err)
+	assert.Equal(jobs.StateDone, job.State)
+	assert.Equal(ordered.ID, job.TranscriptID)
+	info, err := client.GetTranscriptInfo(mediaFileID, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal(ordered.ID, info.ID)
+}
+
+func TestRunFailsOnRejectedTranscript(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	var failedFrom jobs.State
+	runner := jobs.NewRunner(client, jobs.NewMemoryStore(), fastWait,
+		jobs.WithTransitionHook(func(ctx context.Context, job *jobs.Job, from jobs.State) {
+			switch job.State {
+			case jobs.StateOrdered:
+				id, _ := strconv.Atoi(job.MediaFileID)
+				server.RejectTranscript(id, "media_error", "No audio track")
+			case jobs.StateFailed:
+				failedFrom = from
+			}
+		}),
+	)
+	assert.Nil(runner.Submit(context.Background(), newJob("silent")))
+
+	job, err := runner.Run(context.Background(), "silent")
+	assert.True(errors.Is(err, v3api.ErrTranscriptCancelled))
+	assert.Equal(jobs.StateFailed, job.State)
+	assert.Equal(jobs.StateOrdered, failedFrom)
+	assert.Equal(1, job.Attempts)
+	assert.Contains(job.LastError, "cancelled")
+}
+
+func TestRunFailsOnErrorsInPayload(t *testing.T) {
+	assert := assert.New(t)
+	// legacy errors come with a 200 status
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"iserror":true,"errors":{"not_found":"No such account"}}`))
+	}))
+	defer server.Close()
+	client := v3api.NewClient("api-key", v3api.WithBaseURL(server.URL))
+	runner := jobs.NewRunner(client, jobs.NewMemoryStore(), fastWait)
+	assert.Nil(runner.Submit(context.Background(), newJob("speech")))
+
+	job, err := runner.Run(context.Background(), "speech")
+	assert.True(errors.Is(err, v3api.ErrNotFound))
+	assert.Equal(jobs.StateFailed, job.State)
+	assert.Equal(1, job.Attempts)
+}
+
+func TestRunRetriesTransientErrors(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	store := jobs.NewMemoryStore()
+	publishErr := errors.New("cdn unavailable")
+	runner := jobs.NewRunner(client, store, completeOnOrder(server), fastWait, jobs.WithMaxAttempts(2),
+		jobs.WithPublisher(func(ctx context.Context, job *jobs.Job) error { return publishErr }),
+	)
+	assert.Nil(runner.Submit(context.Background(), newJob("speech")))
+
+	job, err := runner.Run(context.Background(), "speech")
+	assert.Equal(publishErr, err)
+	assert.Equal(jobs.StateDownloaded, job.State)
+	assert.Equal(1, job.Attempts)
+	saved, err := store.Get(context.Background(), "speech")
+	assert.Nil(err)
+	assert.Equal("cdn unavailable", saved.LastError)
+
+	job, err = runner.Run(context.Background(), "speech")
+	assert.Equal(publishErr, err)
+	assert.Equal(jobs.StateFailed, job.State)
+	assert.Equal(2, job.Attempts)
+}
+
+func TestResumeBoundsConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	var (
+		mu                sync.Mutex
+		active, maxActive int
+	)
+	runner := jobs.NewRunner(client, jobs.NewMemoryStore(), completeOnOrder(server), fastWait, jobs.WithConcurrency(2),
+		jobs.WithPublisher(func(ctx context.Context, job *jobs.Job) error {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			return nil
+		}),
+	)
+	for i := 0; i < 6; i++ {
+		assert.Nil(runner.Submit(context.Background(), newJob("job-"+strconv.Itoa(i))))
+	}
+
+	assert.Nil(runner.Resume(context.Background()))
+	assert.True(maxActive <= 2)
+	for i := 0; i < 6; i++ {
+		job, err := runner.Run(context.Background(), "job-"+strconv.Itoa(i))
+		assert.Nil(err)
+		assert.Equal(jobs.StateDone, job.State)
+	}
+}
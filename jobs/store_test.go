@@ -0,0 +1,77 @@
+package jobs_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/jobs"
+	"github.com/nytimes/threeplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStore(t *testing.T, store jobs.Store) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := store.Get(ctx, "missing")
+	assert.Equal(jobs.ErrNotFound, err)
+	assert.Equal(jobs.ErrNotFound, store.Update(ctx, &jobs.Job{ID: "missing"}))
+
+	second := &jobs.Job{ID: "b/2", SourceURL: "https://somewhere.com/2.mp4", State: jobs.StatePending, CreatedAt: created.Add(time.Minute)}
+	first := &jobs.Job{ID: "a 1", SourceURL: "https://somewhere.com/1.mp4", State: jobs.StatePending, CreatedAt: created}
+	assert.Nil(store.Create(ctx, second))
+	assert.Nil(store.Create(ctx, first))
+	assert.Equal(jobs.ErrExists, store.Create(ctx, first))
+	long := &jobs.Job{ID: strings.Repeat("long id ", 40), State: jobs.StatePending, CreatedAt: created.Add(time.Hour)}
+	assert.Nil(store.Create(ctx, long))
+	assert.Equal(jobs.ErrExists, store.Create(ctx, long))
+	long.State = jobs.StateUploaded
+	assert.Nil(store.Update(ctx, long))
+	job, err := store.Get(ctx, long.ID)
+	assert.Nil(err)
+	assert.Equal(long.ID, job.ID)
+	assert.Equal(jobs.StateUploaded, job.State)
+
+	first.State = jobs.StateDownloaded
+	first.Captions = map[types.CaptionsFormat]string{types.SRT: "1\n"}
+	assert.Nil(store.Update(ctx, first))
+	first.Captions[types.SRT] = "changed after saving"
+
+	job, err = store.Get(ctx, "a 1")
+	assert.Nil(err)
+	assert.Equal(jobs.StateDownloaded, job.State)
+	assert.Equal("1\n", job.Captions[types.SRT])
+	assert.True(created.Equal(job.CreatedAt))
+
+	list, err := store.List(ctx)
+	assert.Nil(err)
+	assert.Len(list, 3)
+	assert.Equal("a 1", list[0].ID)
+	assert.Equal("b/2", list[1].ID)
+	assert.Equal(long.ID, list[2].ID)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, jobs.NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := jobs.NewFileStore(dir)
+	assert.Nil(t, err)
+	testStore(t, store)
+
+	reopened, err := jobs.NewFileStore(dir)
+	assert.Nil(t, err)
+	list, err := reopened.List(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, list, 3)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// V2Hooks returns the hooks attaching the collector to a v2api client with
+// v2api.WithHooks
+func (c *Collector) V2Hooks() v2api.Hooks {
+	return hooks{c: c, api: "v2"}
+}
+
+// V2AccountHooks is like V2Hooks but labels the metrics of the client with
+// the given account name
+func (c *Collector) V2AccountHooks(account string) v2api.Hooks {
+	return hooks{c: c, api: "v2", account: account}
+}
+
+// V3Hooks returns the hooks attaching the collector to a v3api client with
+// v3api.WithHooks
+func (c *Collector) V3Hooks() v3api.Hooks {
+	return hooks{c: c, api: "v3"}
+}
+
+// V3AccountHooks is like V3Hooks but labels the metrics of the client with
+// the given account name
+func (c *Collector) V3AccountHooks(account string) v3api.Hooks {
+	return hooks{c: c, api: "v3", account: account}
+}
+
+// hooks serve both API versions, whose Event types are the same
+type hooks struct {
+	c       *Collector
+	api     string
+	account string
+}
+
+func (h hooks) call(e v3api.Event) call {
+	return call{api: h.api, account: h.account, method: e.Method, endpoint: e.Endpoint}
+}
+
+func (h hooks) OnRequest(ctx context.Context, e v3api.Event) {
+	h.c.observeLimitWait(h.call(e), e.LimitWait)
+}
+
+func (h hooks) OnRetry(ctx context.Context, e v3api.Event) {
+	h.c.observeRetry(h.call(e))
+}
+
+func (h hooks) OnResponse(ctx context.Context, e v3api.Event) {
+	h.c.observeResponse(h.call(e), e.StatusCode, e.Duration)
+}
+
+func (h hooks) OnError(ctx context.Context, e v3api.Event) {
+	h.c.observeError(h.call(e), e.Duration)
+}
+
+func (h hooks) OnAPIError(ctx context.Context, e v3api.Event) {
+	h.c.observeAPIError(h.call(e), e.ErrorType)
+}
@@ -0,0 +1,57 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/nytimes/threeplay/metrics"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, collector *metrics.Collector) string {
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	return rec.Body.String()
+}
+
+func TestV3Hooks(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	collector := metrics.NewCollector()
+	opts := append(server.V3Options(), v3api.WithHooks(collector.V3Hooks()))
+	client := v3api.NewClient(server.APIKey, opts...)
+	id := server.AddFile("video.mp4")
+	_, err := client.GetFile(strconv.Itoa(id), v3api.CallParams{})
+	assert.Nil(err)
+	_, err = client.GetTranscriptInfo(strconv.Itoa(id), v3api.CallParams{})
+	assert.NotNil(err)
+
+	body := scrape(t, collector)
+	assert.Contains(body, `threeplay_requests_total{api="v3",method="GET",endpoint="/v3/files/{id}",code="200"} 1`)
+	assert.Contains(body, `threeplay_requests_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}",code="404"} 1`)
+	assert.Contains(body, `threeplay_errors_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}",type="not_found_error"} 1`)
+	assert.Contains(body, `threeplay_request_duration_seconds_count{api="v3",method="GET",endpoint="/v3/files/{id}"} 1`)
+}
+
+func TestV2Hooks(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	collector := metrics.NewCollector()
+	client := v2api.NewClient("wrong-key", "wrong-secret", append(server.V2Options(), v2api.WithHooks(collector.V2Hooks()))...)
+	_, err := client.GetFile(1)
+	assert.NotNil(err)
+
+	body := scrape(t, collector)
+	assert.Contains(body, `threeplay_requests_total{api="v2",method="GET",endpoint="/files/{id}"`)
+	assert.Contains(body, `threeplay_errors_total{api="v2",method="GET",endpoint="/files/{id}",type="authentication"} 1`)
+}
@@ -0,0 +1,269 @@
+// Package metrics collects request metrics from the v2api and v3api clients
+// and exposes them in the Prometheus text format.
+//
+//	collector := metrics.NewCollector()
+//	client := v3api.NewClient(apiKey, v3api.WithHooks(collector.V3Hooks()))
+//	http.Handle("/metrics", collector)
+//
+// The collector records, labelled by API version, method and endpoint, and by
+// account for clients attached with V2AccountHooks and V3AccountHooks:
+//
+//	threeplay_requests_total                 attempts, by response status
+//	threeplay_errors_total                   failures, by 3Play error type
+//	threeplay_retries_total                  attempts that were retried
+//	threeplay_rate_limit_wait_seconds_total  time waited for the rate limiter
+//	threeplay_request_duration_seconds       histogram of attempt latencies
+//
+// Endpoints are reported as path templates such as /v3/transcripts/{id}, to
+// keep the number of series bounded.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrorTypeNetwork is the error type of attempts that failed without a
+// response
+const ErrorTypeNetwork = "network"
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+// buckets
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Collector accumulates the metrics of the clients it is attached to. It is
+// safe for concurrent use and serves the metrics as an http.Handler.
+type Collector struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	requests   map[string]uint64
+	errors     map[string]uint64
+	retries    map[string]uint64
+	limitWait  map[string]float64
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Option configures a Collector
+type Option func(*Collector)
+
+// WithBuckets sets the upper bounds, in seconds, of the latency histogram
+// buckets. It defaults to DefaultBuckets.
+func WithBuckets(buckets ...float64) Option {
+	return func(c *Collector) {
+		c.buckets = append([]float64(nil), buckets...)
+		sort.Float64s(c.buckets)
+	}
+}
+
+// NewCollector returns an empty Collector
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		buckets:    DefaultBuckets,
+		requests:   map[string]uint64{},
+		errors:     map[string]uint64{},
+		retries:    map[string]uint64{},
+		limitWait:  map[string]float64{},
+		histograms: map[string]*histogram{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// call identifies the calls a series counts
+type call struct {
+	api      string
+	account  string
+	method   string
+	endpoint string
+}
+
+func (c call) labels(extra ...string) string {
+	pairs := []string{"api", c.api}
+	if c.account != "" {
+		pairs = append(pairs, "account", c.account)
+	}
+	pairs = append(pairs, "method", c.method, "endpoint", endpointTemplate(c.endpoint))
+	pairs = append(pairs, extra...)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func (c *Collector) observeResponse(k call, status int, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[k.labels("code", strconv.Itoa(status))]++
+	c.observeDuration(k, d)
+}
+
+func (c *Collector) observeError(k call, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[k.labels("code", "error")]++
+	c.errors[k.labels("type", ErrorTypeNetwork)]++
+	c.observeDuration(k, d)
+}
+
+func (c *Collector) observeAPIError(k call, errorType string) {
+	if errorType == "" {
+		errorType = "unknown"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors[k.labels("type", errorType)]++
+}
+
+func (c *Collector) observeRetry(k call) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retries[k.labels()]++
+}
+
+func (c *Collector) observeLimitWait(k call, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limitWait[k.labels()] += d.Seconds()
+}
+
+// observeDuration must be called with c.mu held
+func (c *Collector) observeDuration(k call, d time.Duration) {
+	key := k.labels()
+	h, ok := c.histograms[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.histograms[key] = h
+	}
+	seconds := d.Seconds()
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Write(w)
+}
+
+// Write writes the metrics in the Prometheus text exposition format
+func (c *Collector) Write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := bufio.NewWriter(w)
+	writeCounter(b, "threeplay_requests_total", "Attempts of 3Play API calls, by response status.", c.requests)
+	writeCounter(b, "threeplay_errors_total", "Failed 3Play API calls, by 3Play error type.", c.errors)
+	writeCounter(b, "threeplay_retries_total", "Attempts of 3Play API calls that were retried.", c.retries)
+
+	const wait = "threeplay_rate_limit_wait_seconds_total"
+	fmt.Fprintf(b, "# HELP %s Time 3Play API call attempts waited for the rate limiter.\n# TYPE %s counter\n", wait, wait)
+	for _, labels := range sortedKeys(c.limitWait) {
+		fmt.Fprintf(b, "%s%s %s\n", wait, labels, formatFloat(c.limitWait[labels]))
+	}
+
+	const name = "threeplay_request_duration_seconds"
+	fmt.Fprintf(b, "# HELP %s Latency of 3Play API call attempts.\n# TYPE %s histogram\n", name, name)
+	for _, labels := range sortedHistogramKeys(c.histograms) {
+		h := c.histograms[labels]
+		prefix := labels[:len(labels)-1] + `,le="`
+		for i, bound := range c.buckets {
+			fmt.Fprintf(b, "%s_bucket%s%s\"} %d\n", name, prefix, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s+Inf\"} %d\n", name, prefix, h.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", name, labels, h.count)
+	}
+	return b.Flush()
+}
+
+func writeCounter(w io.Writer, name, help string, series map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, labels := range keys {
+		fmt.Fprintf(w, "%s%s %d\n", name, labels, series[labels])
+	}
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedHistogramKeys(m map[string]*histogram) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// endpointTemplate replaces the IDs and names in an endpoint path with
+// placeholders: /files/123/tags/news becomes /files/{id}/tags/{name}
+func endpointTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		switch previous := segments[i-1]; {
+		case previous == "files", previous == "transcripts" && segments[i] != "order":
+			segments[i] = "{id}"
+		case previous == "tags", previous == "output_formats":
+			segments[i] = "{name}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCollector(WithBuckets(1, 0.1))
+	transcript := call{api: "v3", method: "GET", endpoint: "/v3/transcripts/123"}
+	c.observeResponse(transcript, 500, 50*time.Millisecond)
+	c.observeRetry(transcript)
+	c.observeLimitWait(transcript, 250*time.Millisecond)
+	c.observeLimitWait(transcript, 0)
+	c.observeResponse(transcript, 200, 500*time.Millisecond)
+	c.observeError(call{api: "v2", method: "POST", endpoint: "/files"}, 2*time.Second)
+	c.observeAPIError(call{api: "v3", method: "GET", endpoint: "/v3/transcripts/456"}, "not_found_error")
+
+	var b bytes.Buffer
+	assert.Nil(c.Write(&b))
+	assert.Equal(`# HELP threeplay_requests_total Attempts of 3Play API calls, by response status.
+# TYPE threeplay_requests_total counter
+threeplay_requests_total{api="v2",method="POST",endpoint="/files",code="error"} 1
+threeplay_requests_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}",code="200"} 1
+threeplay_requests_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}",code="500"} 1
+# HELP threeplay_errors_total Failed 3Play API calls, by 3Play error type.
+# TYPE threeplay_errors_total counter
+threeplay_errors_total{api="v2",method="POST",endpoint="/files",type="network"} 1
+threeplay_errors_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}",type="not_found_error"} 1
+# HELP threeplay_retries_total Attempts of 3Play API calls that were retried.
+# TYPE threeplay_retries_total counter
+threeplay_retries_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}"} 1
+# HELP threeplay_rate_limit_wait_seconds_total Time 3Play API call attempts waited for the rate limiter.
+# TYPE threeplay_rate_limit_wait_seconds_total counter
+threeplay_rate_limit_wait_seconds_total{api="v3",method="GET",endpoint="/v3/transcripts/{id}"} 0.25
+# HELP threeplay_request_duration_seconds Latency of 3Play API call attempts.
+# TYPE threeplay_request_duration_seconds histogram
+threeplay_request_duration_seconds_bucket{api="v2",method="POST",endpoint="/files",le="0.1"} 0
+threeplay_request_duration_seconds_bucket{api="v2",method="POST",endpoint="/files",le="1"} 0
+threeplay_request_duration_seconds_bucket{api="v2",method="POST",endpoint="/files",le="+Inf"} 1
+threeplay_request_duration_seconds_sum{api="v2",method="POST",endpoint="/files"} 2
+threeplay_request_duration_seconds_count{api="v2",method="POST",endpoint="/files"} 1
+threeplay_request_duration_seconds_bucket{api="v3",method="GET",endpoint="/v3/transcripts/{id}",le="0.1"} 1
+threeplay_request_duration_seconds_bucket{api="v3",method="GET",endpoint="/v3/transcripts/{id}",le="1"} 2
+threeplay_request_duration_seconds_bucket{api="v3",method="GET",endpoint="/v3/transcripts/{id}",le="+Inf"} 2
+threeplay_request_duration_seconds_sum{api="v3",method="GET",endpoint="/v3/transcripts/{id}"} 0.55
+threeplay_request_duration_seconds_count{api="v3",method="GET",endpoint="/v3/transcripts/{id}"} 2
+`, b.String())
+}
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/files":                              "/files",
+		"/files/123":                          "/files/{id}",
+		"/files/abc-1/captions.srt":           "/files/{id}/captions.srt",
+		"/files/123/tags/breaking news":       "/files/{id}/tags/{name}",
+		"/files/123/output_formats/custom":    "/files/{id}/output_formats/{name}",
+		"/v3/output_formats":                  "/v3/output_formats",
+		"/v3/files/123/archive":               "/v3/files/{id}/archive",
+		"/v3/transcripts/123/text":            "/v3/transcripts/{id}/text",
+		"/v3/transcripts/order/transcription": "/v3/transcripts/order/transcription",
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, endpointTemplate(path), path)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert.Equal(t, `a\"b\\c\nd`, escapeLabel("a\"b\\c\nd"))
+}
+
+func TestAccountLabel(t *testing.T) {
+	c := call{api: "v2", account: "sports", method: "GET", endpoint: "/files/1"}
+	assert.Equal(t, `{api="v2",account="sports",method="GET",endpoint="/files/{id}",code="200"}`, c.labels("code", "200"))
+}
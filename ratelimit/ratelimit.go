@@ -0,0 +1,186 @@
+// Package ratelimit throttles the requests the v2api and v3api clients send,
+// so bulk jobs stay within the limits 3Play enforces instead of tripping them
+// and retrying.
+//
+// A Limiter keeps a token bucket for each API key and host: requests to the
+// API and downloads from the static host have separate budgets. One Limiter
+// can be shared by any number of clients and goroutines:
+//
+//	limiter := ratelimit.New(ratelimit.Limit{Rate: 5, Burst: 10},
+//		ratelimit.WithStaticLimit(ratelimit.Limit{Rate: 20, Burst: 20}))
+//	v2 := v2api.NewClient(apiKey, apiSecret, v2api.WithRateLimiter(limiter))
+//	v3 := v3api.NewClient(apiKey, v3api.WithRateLimiter(limiter))
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Host identifies the 3Play host a request is sent to
+type Host string
+
+// Hosts with separate budgets
+const (
+	API    Host = "api"
+	Static Host = "static"
+)
+
+// Limit is the budget of a token bucket. The zero value doesn't limit
+// requests.
+type Limit struct {
+	// Rate is the number of requests per second allowed on average
+	Rate float64
+	// Burst is the number of requests that can be sent at once after a
+	// quiet period. It is at least 1.
+	Burst int
+}
+
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Limiter waits for the budget of each request to be available. It is safe
+// for concurrent use.
+type Limiter struct {
+	limits map[Host]Limit
+	keys   map[string]map[Host]Limit
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+type bucketKey struct {
+	host   Host
+	apiKey string
+}
+
+// Option configures a Limiter
+type Option func(*Limiter)
+
+// WithStaticLimit sets the budget of downloads from the static host. It
+// defaults to the API budget.
+func WithStaticLimit(limit Limit) Option {
+	return func(l *Limiter) {
+		l.limits[Static] = limit
+	}
+}
+
+// WithKeyLimit sets the budgets of the given API key, for accounts whose
+// limits differ from the default
+func WithKeyLimit(apiKey string, api, static Limit) Option {
+	return func(l *Limiter) {
+		l.keys[apiKey] = map[Host]Limit{API: api, Static: static}
+	}
+}
+
+// WithClock sets the source of the current time, for tests
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// New returns a Limiter giving each API key the given budget for API requests
+func New(api Limit, opts ...Option) *Limiter {
+	l := &Limiter{
+		limits:  map[Host]Limit{API: api, Static: api},
+		keys:    map[string]map[Host]Limit{},
+		now:     time.Now,
+		buckets: map[bucketKey]*bucket{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Wait blocks until a request with the given API key can be sent to host and
+// returns how long it waited. It returns the context error without taking
+// from the budget when ctx is done first, or right away when ctx expires
+// before the wait would end.
+func (l *Limiter) Wait(ctx context.Context, host Host, apiKey string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	b := l.bucket(host, apiKey)
+	if b == nil {
+		l.mu.Unlock()
+		return 0, nil
+	}
+	now := l.now()
+	wait := b.reserve(now)
+	if deadline, ok := ctx.Deadline(); ok && wait > 0 && now.Add(wait).After(deadline) {
+		b.cancel()
+		l.mu.Unlock()
+		return 0, context.DeadlineExceeded
+	}
+	l.mu.Unlock()
+	if wait <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.cancel()
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	case <-timer.C:
+		return wait, nil
+	}
+}
+
+// bucket returns the bucket of the key on host, nil when its requests aren't
+// limited. It must be called with l.mu held.
+func (l *Limiter) bucket(host Host, apiKey string) *bucket {
+	key := bucketKey{host, apiKey}
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+	limit, ok := l.keys[apiKey][host]
+	if !ok {
+		limit = l.limits[host]
+	}
+	if limit.unlimited() {
+		l.buckets[key] = nil
+		return nil
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	b := &bucket{limit: limit, tokens: float64(limit.Burst), last: l.now()}
+	l.buckets[key] = b
+	return b
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait until it's available.
+// Tokens go negative while requests are queued.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed.Seconds()*b.limit.Rate)
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.limit.Rate * float64(time.Second))
+}
+
+// cancel gives back the token of a reservation that wasn't used
+func (b *bucket) cancel() {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+1)
+}
@@ -0,0 +1,131 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func expiring() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 50*time.Millisecond)
+}
+
+func TestWaitAllowsBursts(t *testing.T) {
+	assert := assert.New(t)
+	c := &clock{now: time.Now()}
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 3}, ratelimit.WithClock(c.Now))
+
+	for i := 0; i < 3; i++ {
+		wait, err := limiter.Wait(context.Background(), ratelimit.API, "key")
+		assert.Nil(err)
+		assert.Equal(time.Duration(0), wait)
+	}
+	ctx, cancel := expiring()
+	defer cancel()
+	start := time.Now()
+	_, err := limiter.Wait(ctx, ratelimit.API, "key")
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.True(time.Since(start) < 50*time.Millisecond, "should fail without waiting for the deadline")
+
+	c.Advance(time.Second)
+	wait, err := limiter.Wait(context.Background(), ratelimit.API, "key")
+	assert.Nil(err)
+	assert.Equal(time.Duration(0), wait)
+}
+
+func TestWaitReportsWait(t *testing.T) {
+	assert := assert.New(t)
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 50, Burst: 1})
+
+	_, err := limiter.Wait(context.Background(), ratelimit.API, "key")
+	assert.Nil(err)
+	start := time.Now()
+	wait, err := limiter.Wait(context.Background(), ratelimit.API, "key")
+	assert.Nil(err)
+	assert.True(wait > 0 && wait <= 20*time.Millisecond, wait)
+	assert.True(time.Since(start) >= wait)
+}
+
+func TestBudgetsArePerKeyAndHost(t *testing.T) {
+	assert := assert.New(t)
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 0.001, Burst: 1},
+		ratelimit.WithKeyLimit("unlimited", ratelimit.Limit{}, ratelimit.Limit{}))
+
+	for _, call := range []struct {
+		host   ratelimit.Host
+		apiKey string
+	}{
+		{ratelimit.API, "a"},
+		{ratelimit.API, "b"},
+		{ratelimit.Static, "a"},
+		{ratelimit.API, "unlimited"},
+		{ratelimit.API, "unlimited"},
+	} {
+		wait, err := limiter.Wait(context.Background(), call.host, call.apiKey)
+		assert.Nil(err, call)
+		assert.Equal(time.Duration(0), wait, call)
+	}
+
+	ctx, cancel := expiring()
+	defer cancel()
+	_, err := limiter.Wait(ctx, ratelimit.Static, "a")
+	assert.Equal(context.DeadlineExceeded, err)
+}
+
+func TestWithStaticLimit(t *testing.T) {
+	assert := assert.New(t)
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 0.001, Burst: 1},
+		ratelimit.WithStaticLimit(ratelimit.Limit{Rate: 0.001, Burst: 3}))
+
+	for i := 0; i < 3; i++ {
+		_, err := limiter.Wait(context.Background(), ratelimit.Static, "key")
+		assert.Nil(err)
+	}
+	ctx, cancel := expiring()
+	defer cancel()
+	_, err := limiter.Wait(ctx, ratelimit.Static, "key")
+	assert.Equal(context.DeadlineExceeded, err)
+}
+
+func TestCancelledWaitGivesBackItsToken(t *testing.T) {
+	assert := assert.New(t)
+	c := &clock{now: time.Now()}
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 1}, ratelimit.WithClock(c.Now))
+
+	_, err := limiter.Wait(context.Background(), ratelimit.API, "key")
+	assert.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = limiter.Wait(ctx, ratelimit.API, "key")
+	assert.Equal(context.Canceled, err)
+
+	c.Advance(time.Second)
+	wait, err := limiter.Wait(context.Background(), ratelimit.API, "key")
+	assert.Nil(err)
+	assert.Equal(time.Duration(0), wait)
+}
@@ -0,0 +1,94 @@
+// Package threeplay defines Service, the operations shared by the 3Play v2
+// and v3 APIs, so code can switch between them, e.g. behind a feature flag
+// during a migration, and be tested against a fake of the interface.
+//
+//	var svc threeplay.Service = threeplay.NewV3Service(v3api.NewClient(apiKey))
+//	if useV2 {
+//		svc = threeplay.NewV2Service(v2api.NewClient(apiKey, apiSecret))
+//	}
+//	id, err := svc.Upload(ctx, threeplay.UploadRequest{SourceURL: src})
+//
+// File IDs are passed as decimal strings whatever the API version.
+// Operations an API version lacks return ErrNotSupported.
+package threeplay
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// ErrNotSupported is returned for operations the API version backing a
+// Service doesn't offer
+var ErrNotSupported = errors.New("operation not supported by this 3Play API version")
+
+// Service is the set of operations available from both 3Play API versions
+type Service interface {
+	// Upload adds the media at a URL and returns the ID of the new file
+	Upload(ctx context.Context, req UploadRequest) (string, error)
+	// Order orders the transcript of a file
+	Order(ctx context.Context, fileID string, opts OrderOptions) (*Transcript, error)
+	// Status returns the state of the transcript of a file
+	Status(ctx context.Context, fileID string) (*Transcript, error)
+	// Captions downloads the captions of a file in the given format
+	Captions(ctx context.Context, fileID string, format types.CaptionsFormat) ([]byte, error)
+	// TranscriptText downloads the transcript of a file as plain text
+	TranscriptText(ctx context.Context, fileID string) (string, error)
+	// Cancel cancels the transcript order of a file
+	Cancel(ctx context.Context, fileID string) error
+	// Tags lists the tags of a file
+	Tags(ctx context.Context, fileID string) ([]string, error)
+	// AddTag tags a file and returns its tags
+	AddTag(ctx context.Context, fileID, tag string) ([]string, error)
+	// RemoveTag removes a tag from a file and returns the remaining tags
+	RemoveTag(ctx context.Context, fileID, tag string) ([]string, error)
+}
+
+// UploadRequest describes media to upload
+type UploadRequest struct {
+	// SourceURL is the URL 3Play downloads the media from
+	SourceURL string
+	// Name is the name of the file, 3Play defaults it to the URL's base name
+	Name string
+	// ReferenceID is an ID of the caller's choosing, sent as the video_id
+	// in v2 and the reference_id in v3
+	ReferenceID string
+}
+
+// TurnaroundASR orders machine transcription
+const TurnaroundASR = "asr"
+
+// OrderOptions are the options of a transcript order
+type OrderOptions struct {
+	// Turnaround is TurnaroundASR or the ID of a transcription turnaround
+	// level. It defaults to TurnaroundASR.
+	Turnaround string
+	// CallbackURL is notified when the transcript is complete
+	CallbackURL string
+}
+
+// Status is the state of a transcript order
+type Status string
+
+// Transcript statuses
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusComplete   Status = "complete"
+	StatusCancelled  Status = "cancelled"
+	StatusFailed     Status = "failed"
+)
+
+// Terminal reports whether the status won't change anymore
+func (s Status) Terminal() bool {
+	return s == StatusComplete || s == StatusCancelled || s == StatusFailed
+}
+
+// Transcript is the state of the transcript order of a file
+type Transcript struct {
+	FileID string
+	Status Status
+	// Cancellable reports whether Cancel can still stop the order
+	Cancellable bool
+}
@@ -0,0 +1,54 @@
+package threeplay_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/nytimes/threeplay"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// exerciseService runs the captioning flow shared by both API versions
+func exerciseService(t *testing.T, server *threeplaytest.Server, svc threeplay.Service) string {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	fileID, err := svc.Upload(ctx, threeplay.UploadRequest{
+		SourceURL:   "https://example.com/speech.mp4",
+		ReferenceID: "ref-1",
+	})
+	assert.Nil(err)
+	assert.NotEmpty(fileID)
+
+	ordered, err := svc.Order(ctx, fileID, threeplay.OrderOptions{})
+	assert.Nil(err)
+	assert.Equal(fileID, ordered.FileID)
+	assert.False(ordered.Status.Terminal())
+
+	id, _ := strconv.Atoi(fileID)
+	assert.Nil(server.CompleteTranscript(id))
+	status, err := svc.Status(ctx, fileID)
+	assert.Nil(err)
+	assert.Equal(threeplay.StatusComplete, status.Status)
+
+	captions, err := svc.Captions(ctx, fileID, types.SRT)
+	assert.Nil(err)
+	assert.Contains(string(captions), "00:00:00,000 --> ")
+
+	text, err := svc.TranscriptText(ctx, fileID)
+	assert.Nil(err)
+	assert.NotEmpty(text)
+	return fileID
+}
+
+func TestStatusTerminal(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(threeplay.StatusComplete.Terminal())
+	assert.True(threeplay.StatusCancelled.Terminal())
+	assert.True(threeplay.StatusFailed.Terminal())
+	assert.False(threeplay.StatusPending.Terminal())
+	assert.False(threeplay.StatusInProgress.Terminal())
+}
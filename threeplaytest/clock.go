@@ -0,0 +1,38 @@
+package threeplaytest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the server the current time. Transcript progress is measured
+// against it.
+type Clock interface {
+	Now() time.Time
+}
+
+// ManualClock is a Clock that only moves when advanced, which lets tests
+// decide exactly when ordered transcripts complete.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a ManualClock set to t
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,432 @@
+// Package threeplaytest provides an in-process fake of the 3Play Media API
+// for tests.
+//
+// The Server implements the v2 and v3 endpoints used by the v2api and v3api
+// packages on top of an in-memory store of files, transcripts and tags.
+// Ordered transcripts stay pending until the server clock reaches their
+// turnaround, and captions are rendered from the stored transcripts, so a
+// whole upload, order, poll and download flow can run offline:
+//
+//	server := threeplaytest.NewServer()
+//	defer server.Close()
+//	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+package threeplaytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+const (
+	// DefaultAPIKey is the API key accepted by a Server unless configured
+	DefaultAPIKey = "api-key"
+	// DefaultAPISecret is the API secret accepted by a Server unless configured
+	DefaultAPISecret = "secret-key"
+	// DefaultTurnaround is the time it takes an ordered transcript to complete
+	DefaultTurnaround = time.Hour
+	// StaticPrefix is the path under which the static host is served
+	StaticPrefix = "/static"
+)
+
+// Transcript statuses reported by the server
+const (
+	StatusPending   = "pending"
+	StatusComplete  = "complete"
+	StatusCancelled = "cancelled"
+)
+
+// Server is a fake 3Play Media API
+type Server struct {
+	// URL is the base URL of the API, to be used with WithBaseURL options
+	URL string
+	// APIKey is the API key the server accepts
+	APIKey string
+	// APISecret is the API secret the server accepts on v2 mutations
+	APISecret string
+
+	httpServer *httptest.Server
+	clock      Clock
+	turnaround time.Duration
+	transcript func(name string) v2api.Transcript
+
+	mu     sync.Mutex
+	nextID int
+	files  map[int]*file
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithCredentials sets the API key and secret the server accepts
+func WithCredentials(apiKey, apiSecret string) Option {
+	return func(s *Server) {
+		s.APIKey = apiKey
+		s.APISecret = apiSecret
+	}
+}
+
+// WithClock sets the clock transcripts progress against. It defaults to a
+// ManualClock, moved forward with Server.Advance.
+func WithClock(clock Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// WithTurnaround sets how long ordered transcripts stay pending
+func WithTurnaround(d time.Duration) Option {
+	return func(s *Server) {
+		s.turnaround = d
+	}
+}
+
+// WithTranscripts sets the function used to generate the transcript of an
+// uploaded file from its name. Transcripts can also be set per file with
+// Server.SetTranscript.
+func WithTranscripts(fn func(name string) v2api.Transcript) Option {
+	return func(s *Server) {
+		s.transcript = fn
+	}
+}
+
+// NewServer starts a Server. Callers should Close it when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		APIKey:     DefaultAPIKey,
+		APISecret:  DefaultAPISecret,
+		clock:      NewManualClock(time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)),
+		turnaround: DefaultTurnaround,
+		transcript: SampleTranscript,
+		nextID:     1000,
+		files:      map[int]*file{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// StaticURL returns the base URL of the static host
+func (s *Server) StaticURL() string {
+	return s.URL + StaticPrefix
+}
+
+// V2Options returns the options pointing a v2api.Client at the server
+func (s *Server) V2Options() []v2api.Option {
+	return []v2api.Option{
+		v2api.WithBaseURL(s.URL),
+		v2api.WithStaticBaseURL(s.StaticURL()),
+	}
+}
+
+// V3Options returns the options pointing a v3api.Client at the server
+func (s *Server) V3Options() []v3api.Option {
+	return []v3api.Option{
+		v3api.WithBaseURL(s.URL),
+	}
+}
+
+// Advance moves the server clock forward and delivers the callbacks of the
+// transcripts that completed. It panics when the server was given a Clock
+// other than a *ManualClock.
+func (s *Server) Advance(d time.Duration) {
+	clock, ok := s.clock.(*ManualClock)
+	if !ok {
+		panic("threeplaytest: Advance requires a *ManualClock")
+	}
+	clock.Advance(d)
+	s.DeliverCallbacks()
+}
+
+// Now returns the current time of the server clock
+func (s *Server) Now() time.Time {
+	return s.clock.Now()
+}
+
+// AddFile stores a file as if it was uploaded and returns its ID
+func (s *Server) AddFile(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFile(name).ID
+}
+
+// SetTranscript replaces the transcript served for a file
+func (s *Server) SetTranscript(fileID int, transcript v2api.Transcript) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[fileID]
+	if !ok {
+		return fmt.Errorf("threeplaytest: file %d not found", fileID)
+	}
+	f.Words = transcript
+	return nil
+}
+
+// CompleteTranscript makes the transcript of a file complete right away
+func (s *Server) CompleteTranscript(fileID int) error {
+	err := s.updateTranscript(fileID, func(t *transcript) {
+		t.ReadyAt = s.clock.Now()
+	})
+	if err != nil {
+		return err
+	}
+	s.DeliverCallbacks()
+	return nil
+}
+
+// RejectTranscript cancels the transcript of a file the way 3Play does when
+// it can't process the media, reporting the given reason and details.
+func (s *Server) RejectTranscript(fileID int, reason, details string) error {
+	err := s.updateTranscript(fileID, func(t *transcript) {
+		t.Cancelled = true
+		t.CancellationReason = reason
+		t.CancellationDetails = details
+	})
+	if err != nil {
+		return err
+	}
+	s.DeliverCallbacks()
+	return nil
+}
+
+func (s *Server) updateTranscript(fileID int, update func(*transcript)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[fileID]
+	if !ok || f.Transcript == nil {
+		return fmt.Errorf("threeplaytest: no transcript ordered for file %d", fileID)
+	}
+	update(f.Transcript)
+	return nil
+}
+
+// DeliverCallbacks posts the status of every transcript that reached a
+// terminal status since the last delivery to the callback URL given when it
+// was ordered. The payload is a JSON object with the media_file_id,
+// transcript_id, status, cancellation_reason and cancellation_details fields.
+// It's called by Advance, CompleteTranscript and RejectTranscript; tests
+// using another Clock call it themselves.
+func (s *Server) DeliverCallbacks() {
+	type delivery struct {
+		url     string
+		payload map[string]interface{}
+	}
+	var deliveries []delivery
+
+	s.mu.Lock()
+	now := s.clock.Now()
+	for _, f := range s.sortedFiles() {
+		t := f.Transcript
+		if t == nil || f.CallbackURL == "" {
+			continue
+		}
+		status := t.status(now)
+		if status == StatusPending || status == t.Notified {
+			continue
+		}
+		t.Notified = status
+		deliveries = append(deliveries, delivery{f.CallbackURL, map[string]interface{}{
+			"media_file_id":        f.ID,
+			"transcript_id":        t.ID,
+			"status":               status,
+			"cancellation_reason":  t.CancellationReason,
+			"cancellation_details": t.CancellationDetails,
+		}})
+	}
+	s.mu.Unlock()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, d := range deliveries {
+		body, _ := json.Marshal(d.payload)
+		res, err := client.Post(d.url, "application/json", bytes.NewReader(body))
+		if err == nil {
+			res.Body.Close()
+		}
+	}
+}
+
+// TranscriptStatus returns the status of the transcript ordered for a file,
+// or an empty string when none was ordered
+func (s *Server) TranscriptStatus(fileID int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[fileID]
+	if !ok || f.Transcript == nil {
+		return ""
+	}
+	return f.Transcript.status(s.clock.Now())
+}
+
+// UploadedSize returns the number of media bytes uploaded for a file, which
+// is zero for files uploaded by URL
+func (s *Server) UploadedSize(fileID int) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[fileID]
+	if !ok {
+		return 0
+	}
+	return f.Size
+}
+
+// Tags returns the tags of a file
+func (s *Server) Tags(fileID int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[fileID]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), f.Tags...)
+}
+
+// ServeHTTP routes requests to the v2, v3 and static endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := path.Clean(r.URL.Path)
+	switch {
+	case strings.HasPrefix(p, StaticPrefix+"/"):
+		s.serveStatic(w, r, splitPath(strings.TrimPrefix(p, StaticPrefix)))
+	case strings.HasPrefix(p, "/v3/"):
+		s.serveV3(w, r, splitPath(strings.TrimPrefix(p, "/v3")))
+	default:
+		s.serveV2(w, r, splitPath(p))
+	}
+}
+
+type file struct {
+	ID          int
+	Name        string
+	VideoID     string
+	ReferenceID string
+	BatchID     int
+	LanguageID  int
+	Attributes  [3]string
+	CallbackURL string
+	Description string
+	Source      string
+	Size        int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Tags        []string
+	Archived    bool
+	Words       v2api.Transcript
+	Transcript  *transcript
+}
+
+type transcript struct {
+	ID                  int
+	Type                string
+	TurnaroundLevel     string
+	OrderedAt           time.Time
+	ReadyAt             time.Time
+	Cancelled           bool
+	CancellationReason  string
+	CancellationDetails string
+	Notified            string
+}
+
+func (t *transcript) status(now time.Time) string {
+	switch {
+	case t.Cancelled:
+		return StatusCancelled
+	case !now.Before(t.ReadyAt):
+		return StatusComplete
+	default:
+		return StatusPending
+	}
+}
+
+func (s *Server) addFile(name string) *file {
+	s.nextID++
+	now := s.clock.Now()
+	f := &file{
+		ID:         s.nextID,
+		Name:       name,
+		BatchID:    1,
+		LanguageID: 1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Words:      s.transcript(name),
+	}
+	s.files[f.ID] = f
+	return f
+}
+
+func (s *Server) order(f *file, transcriptType, turnaroundLevel string) *transcript {
+	s.nextID++
+	now := s.clock.Now()
+	f.Transcript = &transcript{
+		ID:              s.nextID,
+		Type:            transcriptType,
+		TurnaroundLevel: turnaroundLevel,
+		OrderedAt:       now,
+		ReadyAt:         now.Add(s.turnaround),
+	}
+	f.UpdatedAt = now
+	return f.Transcript
+}
+
+// findFile looks a file up by ID, or by video ID when useVideoID is set
+func (s *Server) findFile(id string, useVideoID bool) (*file, bool) {
+	for _, f := range s.sortedFiles() {
+		if (useVideoID && f.VideoID == id) || (!useVideoID && fmt.Sprint(f.ID) == id) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// sortedFiles returns the files that weren't archived, by ID
+func (s *Server) sortedFiles() []*file {
+	files := make([]*file, 0, len(s.files))
+	for _, f := range s.files {
+		if !f.Archived {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	return files
+}
+
+// SampleTranscript is the default transcript generator. It produces a short
+// transcript with two speakers and two paragraphs, mentioning the file name.
+func SampleTranscript(name string) v2api.Transcript {
+	text := []string{
+		"NARRATOR: This", "is", "the", "transcript", "of", name + ".", "",
+		"-It", "was", "generated", "by", "the", "fake", "server.",
+	}
+	words := make([]v2api.Word, len(text))
+	for i, w := range text {
+		words[i] = v2api.Word{fmt.Sprint(i * 400), w}
+	}
+	return v2api.Transcript{
+		Words:      words,
+		Paragraphs: []int{0, 7 * 400},
+		Speakers:   map[string]string{"0": "NARRATOR"},
+	}
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
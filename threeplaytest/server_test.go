@@ -0,0 +1,197 @@
+package threeplaytest_test
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestV3Flow(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer(threeplaytest.WithTurnaround(10 * time.Minute))
+	defer server.Close()
+
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	callParams := v3api.CallParams{}
+
+	data := url.Values{}
+	data.Set("source_url", "https://somewhere.com/speech.mp4")
+	fileID, err := client.UploadFileFromURL(data, callParams)
+	assert.Nil(err)
+	mediaFileID := strconv.Itoa(fileID)
+
+	transcript, err := client.OrderTranscript(mediaFileID, "", "asr", callParams)
+	assert.Nil(err)
+	assert.Equal("pending", transcript.Status)
+	assert.Equal("AsrTranscript", transcript.Type)
+
+	_, err = client.GetTranscriptText(mediaFileID, "", types.SRT, callParams)
+	assert.True(errors.Is(err, v3api.ErrNotFound))
+
+	server.Advance(10 * time.Minute)
+	transcript, err = client.GetTranscriptInfo(mediaFileID, callParams)
+	assert.Nil(err)
+	assert.Equal("complete", transcript.Status)
+	assert.False(transcript.Cancellable)
+
+	srt, err := client.GetTranscriptText(mediaFileID, "", types.SRT, callParams)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(srt, "1\n00:00:00,000 --> 00:00:02,800\nNARRATOR: This is the transcript of speech.mp4."))
+
+	vtt, err := client.GetTranscriptText(mediaFileID, "", types.WebVTT, callParams)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(vtt, "WEBVTT\n\n00:00:00.000 --> 00:00:02.800\n"))
+
+	link, err := client.GetEditingLink(mediaFileID, 2, callParams)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(link, server.URL))
+}
+
+func TestV3Cancel(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	id := strconv.Itoa(server.AddFile("speech.mp4"))
+
+	_, err := client.OrderTranscript(id, "", "6", v3api.CallParams{})
+	assert.Nil(err)
+	assert.Nil(client.CancelTranscript(id, v3api.CallParams{}))
+	assert.Equal(threeplaytest.StatusCancelled, server.TranscriptStatus(mustAtoi(id)))
+
+	err = client.CancelTranscript(id, v3api.CallParams{})
+	var apiErr *v3api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(v3api.ErrorTypeForbidden, apiErr.Type)
+}
+
+func TestV3RejectedTranscript(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	fileID := server.AddFile("speech.mp4")
+	id := strconv.Itoa(fileID)
+	_, err := client.OrderTranscript(id, "", "asr", v3api.CallParams{})
+	assert.Nil(err)
+
+	assert.Nil(server.RejectTranscript(fileID, "media_error", "No audio track"))
+	transcript, err := client.GetTranscriptInfo(id, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal("cancelled", transcript.Status)
+	assert.Equal("media_error", transcript.CancellationReason)
+	assert.Equal("No audio track", transcript.CancellationDetails)
+}
+
+func TestV3Unauthorized(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	client := v3api.NewClient("wrong-key", server.V3Options()...)
+	_, err := client.GetTranscriptInfo("1", v3api.CallParams{})
+	assert.True(errors.Is(err, v3api.ErrUnauthorized))
+
+	_, err = client.GetTranscriptInfo("1", v3api.CallParams{APIKey: server.APIKey})
+	assert.True(errors.Is(err, v3api.ErrNotFound))
+}
+
+func TestV2Flow(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+
+	options := url.Values{}
+	options.Set("video_id", "vid-1")
+	fileID, err := client.UploadFileFromURL("https://somewhere.com/speech.mp4", options)
+	assert.Nil(err)
+
+	file, err := client.GetFile(fileID)
+	assert.Nil(err)
+	assert.Equal("speech.mp4", file.Name)
+	assert.Equal("in_progress", file.State)
+
+	_, err = client.GetCaptions(v2api.GetCaptionsOptions{FileID: fileID, Format: types.SRT})
+	assert.True(errors.Is(err, v2api.ErrNotFound))
+
+	server.Advance(threeplaytest.DefaultTurnaround)
+	file, err = client.GetFile(fileID)
+	assert.Nil(err)
+	assert.Equal("complete", file.State)
+
+	captions, err := client.GetCaptionsByVideoID("vid-1", types.WebVTT)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(string(captions), "WEBVTT\n"))
+
+	transcript, err := client.GetTranscript(fileID)
+	assert.Nil(err)
+	assert.Equal("NARRATOR: This", transcript.Words[0][1])
+
+	text, err := client.GetTranscriptWithFormat(fileID, v2api.TXT)
+	assert.Nil(err)
+	assert.Equal("NARRATOR: This is the transcript of speech.mp4. -It was generated by the fake server.", string(text))
+}
+
+func TestV2FilesAndTags(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+	for i := 0; i < 3; i++ {
+		server.AddFile("file-" + strconv.Itoa(i))
+	}
+	fileID := uint(server.AddFile("tagged"))
+	assert.Nil(client.UpdateFile(fileID, url.Values{"video_id": {"vid-2"}}))
+
+	page, err := client.GetFiles(url.Values{"per_page": {"3"}, "page": {"2"}}, nil)
+	assert.Nil(err)
+	assert.Len(page.Files, 1)
+	assert.Equal("4", page.Summary.TotalEntries.String())
+	assert.Equal("2", page.Summary.TotalPages.String())
+
+	page, err = client.GetFiles(nil, url.Values{"video_id": {"vid-2"}})
+	assert.Nil(err)
+	assert.Len(page.Files, 1)
+	assert.Equal("tagged", page.Files[0].Name)
+
+	tags, err := client.AddTag(fileID, "news")
+	assert.Nil(err)
+	assert.Equal([]string{"news"}, tags)
+	_, err = client.AddTag(fileID, "politics")
+	assert.Nil(err)
+
+	tags, err = client.RemoveTag(fileID, "news")
+	assert.Nil(err)
+	assert.Equal([]string{"politics"}, tags)
+
+	tags, err = client.GetTags(fileID)
+	assert.Nil(err)
+	assert.Equal([]string{"politics"}, tags)
+	assert.Equal([]string{"politics"}, server.Tags(int(fileID)))
+
+	unauthorized := v2api.NewClient(server.APIKey, "wrong-secret", server.V2Options()...)
+	_, err = unauthorized.AddTag(fileID, "news")
+	assert.True(errors.Is(err, v2api.ErrUnauthorized))
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
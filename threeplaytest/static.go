@@ -0,0 +1,161 @@
+package threeplaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+)
+
+// serveStatic handles the static host endpoints:
+//
+//	GET /files/{id}/captions.{format}
+//	GET /files/{id}/transcript.{format}
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request, parts []string) {
+	query := r.URL.Query()
+	if query.Get("apikey") != s.APIKey {
+		writeV2Error(w, http.StatusUnauthorized, "authentication", "Could not authenticate API Access")
+		return
+	}
+	if len(parts) != 3 || parts[0] != "files" || r.Method != http.MethodGet {
+		writeV2Error(w, http.StatusNotFound, "not_found", "Not found")
+		return
+	}
+	f, ok := s.findFile(parts[1], query.Get("usevideoid") == "1")
+	if !ok {
+		writeV2Error(w, http.StatusNotFound, "not_found", "File not found")
+		return
+	}
+	if f.Transcript == nil || f.Transcript.status(s.clock.Now()) != StatusComplete {
+		writeV2Error(w, http.StatusNotFound, "not_found", "Transcript not available")
+		return
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(parts[2], "captions."):
+		body, err = renderCaptions(f.Words, types.CaptionsFormat(strings.TrimPrefix(parts[2], "captions.")))
+	case strings.HasPrefix(parts[2], "transcript."):
+		body, err = renderTranscript(f.Words, v2api.TranscriptFormat(strings.TrimPrefix(parts[2], "transcript.")))
+	default:
+		writeV2Error(w, http.StatusNotFound, "not_found", "Not found")
+		return
+	}
+	if err != nil {
+		writeV2Error(w, http.StatusBadRequest, "format", err.Error())
+		return
+	}
+	w.Write(body)
+}
+
+// cue is a caption rendered from consecutive transcript words
+type cue struct {
+	Start, End time.Duration
+	Text       string
+}
+
+const maxCueWords = 7
+
+// buildCues groups the transcript words into cues of a few words, starting a
+// new cue at every paragraph
+func buildCues(t v2api.Transcript) []cue {
+	paragraphs := map[int]bool{}
+	for _, p := range t.Paragraphs {
+		paragraphs[p] = true
+	}
+	var (
+		cues  []cue
+		words []string
+		start time.Duration
+	)
+	flush := func(end time.Duration) {
+		if len(words) > 0 {
+			cues = append(cues, cue{Start: start, End: end, Text: strings.Join(words, " ")})
+		}
+		words = nil
+	}
+	for i, w := range t.Words {
+		ms := w.Start()
+		if paragraphs[int(ms/time.Millisecond)] || len(words) == maxCueWords {
+			flush(ms)
+		}
+		if w.IsSilence() {
+			continue
+		}
+		if len(words) == 0 {
+			start = ms
+		}
+		words = append(words, w[1])
+		if i == len(t.Words)-1 {
+			flush(ms + v2api.DefaultLastWordDuration)
+		}
+	}
+	flush(duration(t))
+	return cues
+}
+
+func renderCaptions(t v2api.Transcript, format types.CaptionsFormat) ([]byte, error) {
+	var b strings.Builder
+	switch format {
+	case types.SRT:
+		for i, c := range buildCues(t) {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.Start, ","), timestamp(c.End, ","), c.Text)
+		}
+	case types.WebVTT:
+		b.WriteString("WEBVTT\n\n")
+		for _, c := range buildCues(t) {
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n", timestamp(c.Start, "."), timestamp(c.End, "."), c.Text)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported captions format %q", format)
+	}
+	return []byte(b.String()), nil
+}
+
+func renderTranscript(t v2api.Transcript, format v2api.TranscriptFormat) ([]byte, error) {
+	var text []string
+	for _, c := range buildCues(t) {
+		text = append(text, c.Text)
+	}
+	switch format {
+	case v2api.JSON:
+		return json.Marshal(t)
+	case v2api.TXT:
+		return []byte(strings.Join(text, " ")), nil
+	case v2api.HTML:
+		return []byte("<p>" + html.EscapeString(strings.Join(text, " ")) + "</p>"), nil
+	default:
+		return nil, fmt.Errorf("unsupported transcript format %q", format)
+	}
+}
+
+func timestamp(d time.Duration, sep string) string {
+	ms := int64(d / time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// duration is the end of the last word of the transcript
+func duration(t v2api.Transcript) time.Duration {
+	if len(t.Words) == 0 {
+		return 0
+	}
+	return t.Words[len(t.Words)-1].Start() + v2api.DefaultLastWordDuration
+}
+
+func wordCount(t v2api.Transcript) int {
+	count := 0
+	for _, w := range t.Words {
+		if !w.IsSilence() {
+			count++
+		}
+	}
+	return count
+}
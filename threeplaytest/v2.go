@@ -0,0 +1,292 @@
+package threeplaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nytimes/threeplay/v2api"
+)
+
+const v2TimeFormat = "2006-01-02T15:04:05.000-07:00"
+
+// serveV2 handles the v2 API endpoints:
+//
+//	GET  /files
+//	POST /files
+//	GET  /files/{id}
+//	PUT  /files/{id}
+//	GET  /files/{id}/tags
+//	POST /files/{id}/tags
+//	POST /files/{id}/tags/{tag} (_method=delete)
+func (s *Server) serveV2(w http.ResponseWriter, r *http.Request, parts []string) {
+	if err := r.ParseForm(); err != nil {
+		writeV2Error(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if !s.v2Authorized(r) {
+		writeV2Error(w, http.StatusUnauthorized, "authentication", "Could not authenticate API Access")
+		return
+	}
+	if len(parts) == 0 || parts[0] != "files" {
+		writeV2Error(w, http.StatusNotFound, "not_found", "Not found")
+		return
+	}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.v2ListFiles(w, r)
+		case http.MethodPost:
+			s.v2UploadFile(w, r)
+		default:
+			writeV2Error(w, http.StatusMethodNotAllowed, "method", "Method not allowed")
+		}
+		return
+	}
+
+	f, ok := s.findFile(parts[1], false)
+	if !ok {
+		writeV2Error(w, http.StatusNotFound, "not_found", "File not found")
+		return
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.v2File(f))
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		s.v2UpdateFile(w, r, f)
+	case len(parts) == 3 && parts[2] == "tags" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, tagsOf(f))
+	case len(parts) == 3 && parts[2] == "tags" && r.Method == http.MethodPost:
+		s.v2AddTag(w, r, f)
+	case len(parts) == 4 && parts[2] == "tags" && r.Method == http.MethodPost && r.PostForm.Get("_method") == "delete":
+		s.v2RemoveTag(w, f, parts[3])
+	default:
+		writeV2Error(w, http.StatusNotFound, "not_found", "Not found")
+	}
+}
+
+// v2Authorized checks the API key, and the API secret on mutations
+func (s *Server) v2Authorized(r *http.Request) bool {
+	if r.Method == http.MethodGet {
+		return r.URL.Query().Get("apikey") == s.APIKey
+	}
+	return r.PostForm.Get("apikey") == s.APIKey && r.PostForm.Get("api_secret_key") == s.APISecret
+}
+
+func (s *Server) v2ListFiles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filters, err := url.ParseQuery(query.Get("q"))
+	if err != nil {
+		writeV2Error(w, http.StatusBadRequest, "q", err.Error())
+		return
+	}
+	page, perPage := positiveInt(query.Get("page"), 1), positiveInt(query.Get("per_page"), 10)
+
+	var matches []v2api.File
+	for _, f := range s.sortedFiles() {
+		file := s.v2File(f)
+		if matchesV2Filters(file, filters) {
+			matches = append(matches, file)
+		}
+	}
+
+	start, end, totalPages := paginate(len(matches), page, perPage)
+	writeJSON(w, http.StatusOK, v2api.FilesPage{
+		Files: append([]v2api.File{}, matches[start:end]...),
+		Summary: v2api.Summary{
+			CurrentPage:  json.Number(strconv.Itoa(page)),
+			PerPage:      json.Number(strconv.Itoa(perPage)),
+			TotalEntries: json.Number(strconv.Itoa(len(matches))),
+			TotalPages:   json.Number(strconv.Itoa(totalPages)),
+		},
+	})
+}
+
+func matchesV2Filters(file v2api.File, filters url.Values) bool {
+	return matchesFilters(filters, map[string]string{
+		"state":      file.State,
+		"video_id":   file.VideoID,
+		"name":       file.Name,
+		"batch_id":   strconv.FormatUint(uint64(file.BatchID), 10),
+		"attribute1": file.Attribute1,
+		"attribute2": file.Attribute2,
+		"attribute3": file.Attribute3,
+	})
+}
+
+// matchesFilters checks the filters on known fields, ignoring the others
+func matchesFilters(filters url.Values, fields map[string]string) bool {
+	for key, values := range filters {
+		value, known := fields[key]
+		if !known {
+			continue
+		}
+		if !contains(values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) v2UploadFile(w http.ResponseWriter, r *http.Request) {
+	link := r.PostForm.Get("link")
+	if link == "" {
+		writeV2Error(w, http.StatusBadRequest, "link", "link is required")
+		return
+	}
+	name := r.PostForm.Get("name")
+	if name == "" {
+		name = baseName(link)
+	}
+	f := s.addFile(name)
+	f.Source = link
+	f.VideoID = r.PostForm.Get("video_id")
+	f.CallbackURL = r.PostForm.Get("callback_url")
+	applyV2Attributes(f, r.PostForm)
+	s.order(f, "TranscriptionTranscript", r.PostForm.Get("turnaround_level"))
+	w.Write([]byte(strconv.Itoa(f.ID)))
+}
+
+func (s *Server) v2UpdateFile(w http.ResponseWriter, r *http.Request, f *file) {
+	if name := r.PostForm.Get("name"); name != "" {
+		f.Name = name
+	}
+	if videoID := r.PostForm.Get("video_id"); videoID != "" {
+		f.VideoID = videoID
+	}
+	if description := r.PostForm.Get("description"); description != "" {
+		f.Description = description
+	}
+	applyV2Attributes(f, r.PostForm)
+	f.UpdatedAt = s.clock.Now()
+	w.Write([]byte("1"))
+}
+
+func applyV2Attributes(f *file, form url.Values) {
+	for i := range f.Attributes {
+		if value := form.Get(fmt.Sprintf("attribute%d", i+1)); value != "" {
+			f.Attributes[i] = value
+		}
+	}
+}
+
+func (s *Server) v2AddTag(w http.ResponseWriter, r *http.Request, f *file) {
+	name := r.PostForm.Get("name")
+	if name == "" {
+		writeV2Error(w, http.StatusBadRequest, "name", "name is required")
+		return
+	}
+	if !contains(f.Tags, name) {
+		f.Tags = append(f.Tags, name)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"result":          true,
+		"media_file_tags": tagsOf(f),
+	})
+}
+
+func (s *Server) v2RemoveTag(w http.ResponseWriter, f *file, tag string) {
+	tags := f.Tags[:0]
+	for _, t := range f.Tags {
+		if t != tag {
+			tags = append(tags, t)
+		}
+	}
+	f.Tags = tags
+	writeJSON(w, http.StatusOK, tagsOf(f))
+}
+
+func (s *Server) v2File(f *file) v2api.File {
+	file := v2api.File{
+		ID:          uint(f.ID),
+		BatchID:     uint(f.BatchID),
+		Attribute1:  f.Attributes[0],
+		Attribute2:  f.Attributes[1],
+		Attribute3:  f.Attributes[2],
+		VideoID:     f.VideoID,
+		Name:        f.Name,
+		CallbackURL: f.CallbackURL,
+		Description: f.Description,
+		CreatedAt:   f.CreatedAt.Format(v2TimeFormat),
+		UpdatedAt:   f.UpdatedAt.Format(v2TimeFormat),
+		LanguageID:  f.LanguageID,
+		BatchName:   "Default",
+		State:       "not_ordered",
+	}
+	if f.Transcript != nil {
+		file.Deadline = f.Transcript.ReadyAt.Format(v2TimeFormat)
+		switch f.Transcript.status(s.clock.Now()) {
+		case StatusComplete:
+			file.State = "complete"
+			file.WordCount = uint(wordCount(f.Words))
+			file.Duration = uint(duration(f.Words) / time.Millisecond)
+		case StatusCancelled:
+			file.State = "cancelled"
+			file.ErrorDescription = f.Transcript.CancellationDetails
+		default:
+			file.State = "in_progress"
+		}
+	}
+	return file
+}
+
+func writeV2Error(w http.ResponseWriter, status int, key, message string) {
+	writeJSON(w, status, v2api.Error{
+		IsError: true,
+		Errors:  map[string]string{key: message},
+	})
+}
+
+func tagsOf(f *file) []string {
+	return append([]string{}, f.Tags...)
+}
+
+// paginate returns the bounds of a page and the number of pages
+func paginate(total, page, perPage int) (start, end, pages int) {
+	pages = (total + perPage - 1) / perPage
+	start = (page - 1) * perPage
+	end = start + perPage
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	return start, end, pages
+}
+
+func positiveInt(value string, fallback int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func baseName(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	parts := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+	return parts[len(parts)-1]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
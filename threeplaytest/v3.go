@@ -0,0 +1,268 @@
+package threeplaytest
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// maxMemory is the part of multipart uploads kept in memory, the rest is
+// spooled to disk
+const maxMemory = 1 << 20
+
+// v3OutputFormats maps the output format IDs the server renders
+var v3OutputFormats = map[string]types.CaptionsFormat{
+	"7":   types.SRT,
+	"139": types.WebVTT,
+}
+
+// serveV3 handles the v3 API endpoints:
+//
+//	GET  /v3/output_formats
+//	GET  /v3/files
+//	POST /v3/files (form with source_url, or multipart with source_file)
+//	GET  /v3/files/{id}
+//	PUT  /v3/files/{id}
+//	POST /v3/files/{id}/archive
+//	POST /v3/transcripts/order/asr
+//	POST /v3/transcripts/order/transcription
+//	GET  /v3/transcripts/{media_file_id}
+//	GET  /v3/transcripts/{media_file_id}/text
+//	POST /v3/transcripts/{media_file_id}/cancel
+//	GET  /v3/transcripts/{media_file_id}/expiring_editing_link
+func (s *Server) serveV3(w http.ResponseWriter, r *http.Request, parts []string) {
+	parse := r.ParseForm
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		parse = func() error { return r.ParseMultipartForm(maxMemory) }
+	}
+	if err := parse(); err != nil {
+		writeV3Error(w, http.StatusBadRequest, v3api.ErrorTypeParameter, err.Error())
+		return
+	}
+	if r.Form.Get("api_key") != s.APIKey {
+		writeV3Error(w, http.StatusUnauthorized, v3api.ErrorTypeAuthentication, "Invalid API key")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "output_formats" && r.Method == http.MethodGet:
+		writeV3Data(w, []v3api.OutputFormat{
+			{ID: 7, Name: "SRT", Extension: "srt"},
+			{ID: 139, Name: "WebVTT", Extension: "vtt"},
+		})
+	case len(parts) == 1 && parts[0] == "files" && r.Method == http.MethodGet:
+		s.v3ListFiles(w, r)
+	case len(parts) == 1 && parts[0] == "files" && r.Method == http.MethodPost:
+		s.v3UploadFile(w, r)
+	case len(parts) >= 2 && parts[0] == "files":
+		f, ok := s.findFile(parts[1], false)
+		if !ok {
+			writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+			return
+		}
+		s.serveV3File(w, r, f, parts[2:])
+	case len(parts) == 3 && parts[0] == "transcripts" && parts[1] == "order" && r.Method == http.MethodPost:
+		s.v3OrderTranscript(w, r, parts[2])
+	case len(parts) >= 2 && parts[0] == "transcripts":
+		f, ok := s.findFile(parts[1], false)
+		if !ok || f.Transcript == nil {
+			writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+			return
+		}
+		s.serveV3Transcript(w, r, f, parts[2:])
+	default:
+		writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+	}
+}
+
+func (s *Server) serveV3Transcript(w http.ResponseWriter, r *http.Request, f *file, parts []string) {
+	action := ""
+	if len(parts) == 1 {
+		action = parts[0]
+	} else if len(parts) > 1 {
+		writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+		return
+	}
+	status := f.Transcript.status(s.clock.Now())
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeV3Data(w, s.v3Transcript(f))
+	case action == "text" && r.Method == http.MethodGet:
+		format, ok := v3OutputFormats[r.Form.Get("output_format_id")]
+		if !ok {
+			writeV3Error(w, http.StatusBadRequest, v3api.ErrorTypeParameter, "Invalid output_format_id")
+			return
+		}
+		if status != StatusComplete {
+			writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Transcript not available")
+			return
+		}
+		body, _ := renderCaptions(f.Words, format)
+		writeV3Data(w, string(body))
+	case action == "cancel" && r.Method == http.MethodPost:
+		if status != StatusPending {
+			writeV3Error(w, http.StatusForbidden, v3api.ErrorTypeForbidden, "You cannot cancel this transcript at this time.")
+			return
+		}
+		f.Transcript.Cancelled = true
+		f.Transcript.CancellationReason = "customer_request"
+		writeV3Data(w, v3api.CancelObjectRepresentation{Success: true})
+	case action == "expiring_editing_link" && r.Method == http.MethodGet:
+		hours := positiveInt(r.Form.Get("hours_until_expiration"), 1)
+		link := s.URL + "/transcripts/" + strconv.Itoa(f.ID) + "/edit?exp_key=" + strconv.Itoa(hours)
+		writeV3Data(w, link)
+	default:
+		writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+	}
+}
+
+func (s *Server) serveV3File(w http.ResponseWriter, r *http.Request, f *file, parts []string) {
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		writeV3Data(w, s.v3File(f))
+	case len(parts) == 0 && r.Method == http.MethodPut:
+		if name := r.PostForm.Get("name"); name != "" {
+			f.Name = name
+		}
+		if referenceID := r.PostForm.Get("reference_id"); referenceID != "" {
+			f.ReferenceID = referenceID
+		}
+		f.BatchID = positiveInt(r.PostForm.Get("batch_id"), f.BatchID)
+		f.UpdatedAt = s.clock.Now()
+		writeV3Data(w, s.v3File(f))
+	case len(parts) == 1 && parts[0] == "archive" && r.Method == http.MethodPost:
+		f.Archived = true
+		writeV3Data(w, s.v3File(f))
+	default:
+		writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+	}
+}
+
+func (s *Server) v3ListFiles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, perPage := positiveInt(query.Get("page"), 1), positiveInt(query.Get("per_page"), 25)
+	var matches []v3api.FileObjectRepresentation
+	for _, f := range s.sortedFiles() {
+		file := s.v3File(f)
+		if matchesFilters(query, map[string]string{
+			"name":         file.Name,
+			"reference_id": file.ReferenceID,
+			"batch_id":     strconv.Itoa(file.BatchID),
+		}) {
+			matches = append(matches, file)
+		}
+	}
+	start, end, totalPages := paginate(len(matches), page, perPage)
+	writeJSON(w, http.StatusOK, v3api.ThreePlayFilesResponse{
+		Code: http.StatusOK,
+		Data: append([]v3api.FileObjectRepresentation{}, matches[start:end]...),
+		Meta: v3api.PageMeta{
+			CurrentPage:  page,
+			PerPage:      perPage,
+			TotalEntries: len(matches),
+			TotalPages:   totalPages,
+		},
+	})
+}
+
+func (s *Server) v3UploadFile(w http.ResponseWriter, r *http.Request) {
+	source := r.PostForm.Get("source_url")
+	name := r.PostForm.Get("name")
+	var size int64
+	if r.MultipartForm != nil && len(r.MultipartForm.File["source_file"]) > 0 {
+		upload := r.MultipartForm.File["source_file"][0]
+		source, size = "upload:"+upload.Filename, upload.Size
+		if name == "" {
+			name = upload.Filename
+		}
+	}
+	if name == "" {
+		name = baseName(source)
+	}
+	if name == "" {
+		writeV3Error(w, http.StatusBadRequest, v3api.ErrorTypeParameter, "source_url or source_file is required")
+		return
+	}
+	f := s.addFile(name)
+	f.Source = source
+	f.Size = size
+	f.ReferenceID = r.PostForm.Get("reference_id")
+	f.LanguageID = positiveInt(r.PostForm.Get("language_id"), f.LanguageID)
+	f.BatchID = positiveInt(r.PostForm.Get("batch_id"), f.BatchID)
+	writeV3Data(w, s.v3File(f))
+}
+
+func (s *Server) v3OrderTranscript(w http.ResponseWriter, r *http.Request, kind string) {
+	f, ok := s.findFile(r.PostForm.Get("media_file_id"), false)
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+		return
+	}
+	switch kind {
+	case "asr":
+		s.order(f, v3api.TranscriptTypeASR, "")
+	case "transcription":
+		level := r.PostForm.Get("turnaround_level_id")
+		if level == "" {
+			writeV3Error(w, http.StatusBadRequest, v3api.ErrorTypeParameter, "turnaround_level_id is required")
+			return
+		}
+		s.order(f, v3api.TranscriptTypeTranscription, level)
+	default:
+		writeV3Error(w, http.StatusNotFound, v3api.ErrorTypeNotFound, "Not found")
+		return
+	}
+	f.CallbackURL = r.PostForm.Get("callback")
+	writeV3Data(w, s.v3Transcript(f))
+}
+
+func (s *Server) v3File(f *file) v3api.FileObjectRepresentation {
+	return v3api.FileObjectRepresentation{
+		ID:          f.ID,
+		Name:        f.Name,
+		Duration:    duration(f.Words).Seconds(),
+		LanguageID:  f.LanguageID,
+		LanguageIDs: []int{f.LanguageID},
+		BatchID:     f.BatchID,
+		ReferenceID: f.ReferenceID,
+	}
+}
+
+func (s *Server) v3Transcript(f *file) v3api.TranscriptObjectRepresentation {
+	t := f.Transcript
+	status := t.status(s.clock.Now())
+	return v3api.TranscriptObjectRepresentation{
+		ID:                  t.ID,
+		MediaFileID:         f.ID,
+		Duration:            duration(f.Words).Seconds(),
+		Type:                t.Type,
+		LanguageID:          f.LanguageID,
+		Status:              status,
+		Cancellable:         status == StatusPending,
+		CancellationReason:  t.CancellationReason,
+		CancellationDetails: t.CancellationDetails,
+	}
+}
+
+func writeV3Data(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"code": http.StatusOK,
+		"data": data,
+		"meta": map[string]interface{}{},
+	})
+}
+
+func writeV3Error(w http.ResponseWriter, status int, errorType, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"code": status,
+		"error": v3api.ThreePlayError{
+			Type:    errorType,
+			Message: message,
+		},
+		"meta": map[string]interface{}{},
+	})
+}
@@ -3,6 +3,13 @@ package types
 const ThreePlayHost = "api.3playmedia.com"
 const ThreePlayStaticHost = "static.3playmedia.com"
 
+// ThreePlayBaseURL is the default base URL of the 3Play API
+const ThreePlayBaseURL = "https://" + ThreePlayHost
+
+// ThreePlayStaticBaseURL is the default base URL of the 3Play static host,
+// which serves captions and transcripts
+const ThreePlayStaticBaseURL = "https://" + ThreePlayStaticHost
+
 // CaptionsFormat is supported output format for captions
 type CaptionsFormat string
 
@@ -1,19 +1,29 @@
 package v2api
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/url"
 	"strconv"
 
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/internal/transport"
 	"github.com/nytimes/threeplay/types"
 )
 
 // GetCaptionsByVideoID get captions by video ID with specific format
 // current supported formats are srt, dfxp, smi, stl, qt, qtxml, cptxml, adbe
 func (c *Client) GetCaptionsByVideoID(id string, format types.CaptionsFormat) ([]byte, error) {
-	return c.GetCaptions(GetCaptionsOptions{
+	return c.GetCaptionsByVideoIDContext(context.Background(), id, format)
+}
+
+// GetCaptionsByVideoIDContext is like GetCaptionsByVideoID but carries ctx
+// through the request and its retries.
+func (c *Client) GetCaptionsByVideoIDContext(ctx context.Context, id string, format types.CaptionsFormat) ([]byte, error) {
+	return c.GetCaptionsContext(ctx, GetCaptionsOptions{
 		VideoID: id,
 		Format:  format,
 	})
@@ -42,25 +52,43 @@ type GetCaptionsOptions struct {
 
 // GetCaptions retrieves caption files according to the given options.
 func (c *Client) GetCaptions(opts GetCaptionsOptions) ([]byte, error) {
-	endpoint, err := c.getEndpoint(opts)
-	if err != nil {
-		return nil, err
-	}
+	return c.GetCaptionsContext(context.Background(), opts)
+}
 
-	response, err := c.httpClient.Get(endpoint)
-	if err != nil {
+// GetCaptionsContext is like GetCaptions but carries ctx through the request
+// and its retries.
+func (c *Client) GetCaptionsContext(ctx context.Context, opts GetCaptionsOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := c.GetCaptionsTo(ctx, &buf, opts); err != nil {
 		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	responseData, err := ioutil.ReadAll(response.Body)
+// GetCaptionsTo is like GetCaptionsContext but streams the captions to w
+// instead of holding them in memory, and returns the number of bytes written.
+// Captions larger than the client's maximum download size fail with
+// ErrBodyTooLarge once that many bytes were written.
+func (c *Client) GetCaptionsTo(ctx context.Context, w io.Writer, opts GetCaptionsOptions) (int64, error) {
+	endpoint, err := c.getEndpoint(opts)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	return c.download(transport.MarkStatic(ctx), w, endpoint)
+}
 
-	if err := checkForAPIError(responseData); err != nil {
+// GetCaptionsParsed downloads captions in one of the formats supported by the
+// captions package and parses them into cues. Custom output formats can't be
+// parsed.
+func (c *Client) GetCaptionsParsed(ctx context.Context, opts GetCaptionsOptions) ([]captions.Cue, error) {
+	if opts.OutputFormat != "" {
+		return nil, errors.New("cannot parse captions in a custom output format")
+	}
+	data, err := c.GetCaptionsContext(ctx, opts)
+	if err != nil {
 		return nil, err
 	}
-	return responseData, nil
+	return captions.Parse(bytes.NewReader(data), opts.Format)
 }
 
 func (c *Client) getEndpoint(opts GetCaptionsOptions) (string, error) {
@@ -69,7 +97,6 @@ func (c *Client) getEndpoint(opts GetCaptionsOptions) (string, error) {
 		path string
 	)
 	params := url.Values{}
-	params.Set("apikey", c.apiKey)
 
 	switch {
 	case opts.FileID != 0:
@@ -90,5 +117,5 @@ func (c *Client) getEndpoint(opts GetCaptionsOptions) (string, error) {
 		return "", errors.New("cannot determine the endpoint: missing format and custom output format")
 	}
 
-	return fmt.Sprintf("https://%s%s?%s", types.ThreePlayStaticHost, path, params.Encode()), nil
+	return c.prepareStaticURL(path, params)
 }
@@ -1,8 +1,12 @@
 package v2api_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/nytimes/threeplay/threeplaytest"
 	"github.com/nytimes/threeplay/types"
 	"github.com/nytimes/threeplay/v2api"
 	"github.com/stretchr/testify/assert"
@@ -170,3 +174,43 @@ func TestGetCaptionsByVideoIDApiError(t *testing.T) {
 	assert.NotNil(err)
 	assert.Equal(v2api.ErrUnauthorized.Error(), err.Error())
 }
+
+func TestGetCaptionsWithStaticBaseURL(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/files/123456/captions.srt", r.URL.Path)
+		assert.Equal("api-key", r.URL.Query().Get("apikey"))
+		w.Write([]byte("1\n00:00:00,000 --> 00:00:01,000\nHello\n"))
+	}))
+	defer server.Close()
+
+	client := v2api.NewClient("api-key", "secret-key", v2api.WithStaticBaseURL(server.URL))
+	result, err := client.GetCaptions(v2api.GetCaptionsOptions{
+		FileID: 123456,
+		Format: types.SRT,
+	})
+	assert.Nil(err)
+	assert.Contains(string(result), "Hello")
+}
+
+func TestGetCaptionsParsed(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+	fileID, err := client.UploadFileFromURL("https://somewhere.com/speech.mp4", nil)
+	assert.Nil(err)
+	assert.Nil(server.CompleteTranscript(int(fileID)))
+
+	for _, format := range []types.CaptionsFormat{types.SRT, types.WebVTT} {
+		cues, err := client.GetCaptionsParsed(context.Background(), v2api.GetCaptionsOptions{FileID: fileID, Format: format})
+		assert.Nil(err)
+		assert.NotEmpty(cues)
+		assert.True(cues[0].End > cues[0].Start)
+		assert.NotEmpty(cues[0].Lines)
+	}
+
+	_, err = client.GetCaptionsParsed(context.Background(), v2api.GetCaptionsOptions{FileID: fileID, OutputFormat: "custom"})
+	assert.EqualError(err, "cannot parse captions in a custom output format")
+}
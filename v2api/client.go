@@ -1,23 +1,58 @@
 package v2api // import "github.com/NYTimes/threeplay
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/nytimes/threeplay/internal/transport"
 	"github.com/nytimes/threeplay/types"
-	"github.com/sethgrid/pester"
 )
 
 // Client 3Play Media API client
 type Client struct {
-	apiKey     string
-	apiSecret  string
-	httpClient *pester.Client
+	apiKey        string
+	apiSecret     string
+	httpClient    *transport.Client
+	baseURL       *url.URL
+	staticBaseURL *url.URL
+	optionErr     error
+	maxBody       int64
+	maxDownload   int64
+}
+
+// Option configures optional behavior of a Client
+type Option func(*Client)
+
+// WithBaseURL sets the base URL used for API calls, for example to point the
+// client at a staging environment or a local stand-in. It defaults to
+// types.ThreePlayBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = c.parseBaseURL(baseURL, c.baseURL)
+	}
+}
+
+// WithStaticBaseURL sets the base URL used to download captions and
+// transcripts. It defaults to types.ThreePlayStaticBaseURL.
+func WithStaticBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.staticBaseURL = c.parseBaseURL(baseURL, c.staticBaseURL)
+	}
+}
+
+// WithMaxBodySize sets the size above which API responses fail with
+// ErrBodyTooLarge. It defaults to 10 MiB. Caption and transcript downloads
+// are capped by WithMaxDownloadSize instead. A size of 0 or less removes the
+// cap.
+func WithMaxBodySize(size int64) Option {
+	return func(c *Client) {
+		c.maxBody = size
+	}
 }
 
 // Error representation of 3Play API error
@@ -34,45 +69,88 @@ var (
 )
 
 // NewClient returns a 3Play Media client
-func NewClient(apiKey, apiSecret string) *Client {
-	return NewClientWithHTTPClient(apiKey, apiSecret, &http.Client{Timeout: 10 * time.Second})
+func NewClient(apiKey, apiSecret string, opts ...Option) *Client {
+	return NewClientWithHTTPClient(apiKey, apiSecret, &http.Client{Timeout: 10 * time.Second}, opts...)
 }
 
 // NewClientWithHTTPClient returns a 3Play Media client with a custom http client
-func NewClientWithHTTPClient(apiKey, apiSecret string, client *http.Client) *Client {
-	httpClient := pester.NewExtendedClient(client)
-	httpClient.MaxRetries = 5
-	httpClient.Backoff = pester.ExponentialJitterBackoff
-	return &Client{
-		apiKey:     apiKey,
-		apiSecret:  apiSecret,
-		httpClient: httpClient,
+func NewClientWithHTTPClient(apiKey, apiSecret string, client *http.Client, opts ...Option) *Client {
+	baseURL, _ := url.Parse(types.ThreePlayBaseURL)
+	staticBaseURL, _ := url.Parse(types.ThreePlayStaticBaseURL)
+	c := &Client{
+		apiKey:        apiKey,
+		apiSecret:     apiSecret,
+		httpClient:    transport.New(client),
+		baseURL:       baseURL,
+		staticBaseURL: staticBaseURL,
+		maxBody:       transport.DefaultMaxBodySize,
+		maxDownload:   DefaultMaxDownloadSize,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func (c *Client) createURL(endpoint string) url.URL {
-	return url.URL{
-		Scheme: "https",
-		Host:   types.ThreePlayHost,
-		Path:   endpoint,
+// parseBaseURL parses a base URL given through an option. Invalid values are
+// reported by every call made with the client, since constructors can't fail.
+func (c *Client) parseBaseURL(rawURL string, fallback *url.URL) *url.URL {
+	u, err := transport.ParseBaseURL(rawURL)
+	if err != nil {
+		if c.optionErr == nil {
+			c.optionErr = err
+		}
+		return fallback
 	}
+	return u
 }
 
-func (c *Client) prepareURL(u url.URL, querystrings url.Values) string {
+// buildURL is the single place where request URLs are built. A nil query
+// produces a URL without credentials, for requests that send them in the
+// body; otherwise the API key is added to the query string.
+func (c *Client) buildURL(base *url.URL, endpoint string, query url.Values) (string, error) {
+	if c.optionErr != nil {
+		return "", c.optionErr
+	}
+	if query == nil {
+		return transport.BuildURL(base, endpoint, nil), nil
+	}
 	qs := url.Values{}
 	qs.Set("apikey", c.apiKey)
-	for k, v := range querystrings {
+	for k, v := range query {
 		qs[k] = v
 	}
-	u.RawQuery = qs.Encode()
-	return u.String()
+	return transport.BuildURL(base, endpoint, qs), nil
+}
+
+// createURL returns the URL of an API endpoint without credentials
+func (c *Client) createURL(endpoint string) (string, error) {
+	return c.buildURL(c.baseURL, endpoint, nil)
+}
+
+// prepareURL returns the URL of an API endpoint with the API key and the
+// given query string
+func (c *Client) prepareURL(endpoint string, querystrings url.Values) (string, error) {
+	if querystrings == nil {
+		querystrings = url.Values{}
+	}
+	return c.buildURL(c.baseURL, endpoint, querystrings)
 }
 
-func (c *Client) createRequest(method, endpoint string, data url.Values) (*http.Request, error) {
+// prepareStaticURL returns the URL of a static host endpoint with the API key
+// and the given query string
+func (c *Client) prepareStaticURL(endpoint string, querystrings url.Values) (string, error) {
+	if querystrings == nil {
+		querystrings = url.Values{}
+	}
+	return c.buildURL(c.staticBaseURL, endpoint, querystrings)
+}
+
+func (c *Client) createRequest(ctx context.Context, method, endpoint string, data url.Values) (*http.Request, error) {
 	data.Set("apikey", c.apiKey)
 	data.Set("api_secret_key", c.apiSecret)
 	body := strings.NewReader(data.Encode())
-	req, err := http.NewRequest(method, endpoint, body)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
 	if err != nil {
 		return nil, err
 	}
@@ -80,31 +158,35 @@ func (c *Client) createRequest(method, endpoint string, data url.Values) (*http.
 	return req, nil
 }
 
-func parseResponse(res *http.Response, ref interface{}) error {
-	responseData, err := ioutil.ReadAll(res.Body)
+func (c *Client) parseResponse(res *http.Response, ref interface{}) error {
+	responseData, err := c.readResponse(res)
 	if err != nil {
 		return err
 	}
-	err = checkForAPIError(responseData)
+	return json.Unmarshal(responseData, ref)
+}
+
+// readResponse reads and closes the body of res, returning an error when
+// 3Play reported one or the status isn't 2xx
+func (c *Client) readResponse(res *http.Response) ([]byte, error) {
+	responseData, err := transport.ReadBody(res, c.maxBody)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := checkForAPIError(responseData); err != nil {
+		return nil, withResponse(err, res)
 	}
-	err = json.Unmarshal(responseData, ref)
-	return err
+	if !transport.SuccessStatus(res.StatusCode) {
+		return nil, newStatusError(res, responseData)
+	}
+	return responseData, nil
 }
 
 func checkForAPIError(responseData []byte) error {
 	apiError := Error{}
 	json.Unmarshal(responseData, &apiError)
 	if apiError.IsError {
-		if _, ok := apiError.Errors["authentication"]; ok {
-			return ErrUnauthorized
-		}
-		if _, ok := apiError.Errors["not_found"]; ok {
-			return ErrNotFound
-		}
-
-		return errors.New("api error: " + string(responseData))
+		return newAPIError(apiError, responseData)
 	}
 	return nil
 }
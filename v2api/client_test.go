@@ -2,6 +2,10 @@ package v2api
 
 import (
 	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,3 +52,109 @@ func TestCheckForAPIError(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseURLOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	client := NewClient("api-key", "secret-key",
+		WithBaseURL("http://127.0.0.1:8080"),
+		WithStaticBaseURL("http://127.0.0.1:8081/static"),
+	)
+	endpoint, err := client.prepareURL("/files/1", nil)
+	assert.Nil(err)
+	assert.Equal("http://127.0.0.1:8080/files/1?apikey=api-key", endpoint)
+
+	endpoint, err = client.prepareStaticURL("/files/1/transcript.json", nil)
+	assert.Nil(err)
+	assert.Equal("http://127.0.0.1:8081/static/files/1/transcript.json?apikey=api-key", endpoint)
+
+	endpoint, err = client.createURL("/files")
+	assert.Nil(err)
+	assert.Equal("http://127.0.0.1:8080/files", endpoint)
+}
+
+func TestInvalidBaseURLOption(t *testing.T) {
+	assert := assert.New(t)
+
+	client := NewClient("api-key", "secret-key", WithBaseURL("not-a-url"))
+	file, err := client.GetFile(1)
+	assert.Nil(file)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid base URL")
+}
+
+type trackedBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// bodyTracker replies to every request with status and body and remembers
+// the bodies it handed out
+type bodyTracker struct {
+	status int
+	body   string
+
+	mu     sync.Mutex
+	bodies []*trackedBody
+}
+
+func (b *bodyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	body := &trackedBody{Reader: strings.NewReader(b.body)}
+	b.bodies = append(b.bodies, body)
+	return &http.Response{StatusCode: b.status, Body: body, Header: http.Header{}, Request: req}, nil
+}
+
+func TestResponseBodiesAreClosed(t *testing.T) {
+	for _, tracker := range []*bodyTracker{
+		{status: http.StatusOK, body: "123"},
+		{status: http.StatusOK, body: `{"iserror":true,"errors":{"not_found":"no such file"}}`},
+		{status: http.StatusBadGateway, body: "<html>Bad gateway</html>"},
+	} {
+		client := NewClientWithHTTPClient("api-key", "secret-key", &http.Client{Transport: tracker},
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+		client.GetFile(1)
+		client.GetFiles(nil, nil)
+		client.UpdateFile(1, url.Values{"name": {"new"}})
+		client.UploadFileFromURL("https://example.com/video.mp4", nil)
+		client.GetTags(1)
+		client.AddTag(1, "tag")
+		client.RemoveTag(1, "tag")
+		client.GetCaptions(GetCaptionsOptions{FileID: 1, Format: "srt"})
+		client.GetTranscriptWithFormat(1, TXT)
+		client.GetTranscriptByVideoIDWithFormat("abc", TXT)
+
+		assert.Len(t, tracker.bodies, 10)
+		for _, body := range tracker.bodies {
+			assert.True(t, body.closed, "%d %s", tracker.status, tracker.body)
+		}
+	}
+}
+
+func TestWithMaxBodySize(t *testing.T) {
+	assert := assert.New(t)
+	tracker := &bodyTracker{status: http.StatusOK, body: `["a","b","c"]`}
+
+	client := NewClientWithHTTPClient("api-key", "secret-key", &http.Client{Transport: tracker}, WithMaxBodySize(5))
+	tags, err := client.GetTags(1)
+	assert.Nil(tags)
+	assert.True(errors.Is(err, ErrBodyTooLarge))
+
+	client = NewClientWithHTTPClient("api-key", "secret-key", &http.Client{Transport: tracker}, WithMaxBodySize(0))
+	tags, err = client.GetTags(1)
+	assert.Nil(err)
+	assert.Equal([]string{"a", "b", "c"}, tags)
+
+	// downloads have their own cap
+	tracker.body = strings.Repeat("x", 100)
+	client = NewClientWithHTTPClient("api-key", "secret-key", &http.Client{Transport: tracker}, WithMaxBodySize(5))
+	data, err := client.GetTranscriptWithFormat(1, TXT)
+	assert.Nil(err)
+	assert.Len(data, 100)
+}
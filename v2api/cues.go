@@ -0,0 +1,142 @@
+package v2api
+
+import (
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/types"
+)
+
+// Defaults of CueOptions
+const (
+	DefaultMaxLineChars = 32
+	DefaultMaxLines     = 2
+	DefaultMaxDuration  = 6 * time.Second
+	// DefaultLastWordDuration is how long the last word of a transcript
+	// lasts, since there's no next word to end it
+	DefaultLastWordDuration = 500 * time.Millisecond
+)
+
+// CueOptions configures how Transcript.Cues lays out words. The zero value
+// uses the defaults and breaks cues on paragraphs and speaker changes.
+type CueOptions struct {
+	// MaxLineChars is the longest line, speaker label included. Defaults to
+	// DefaultMaxLineChars. Longer words get a line of their own.
+	MaxLineChars int
+	// MaxLines is the number of lines per cue. Defaults to DefaultMaxLines.
+	MaxLines int
+	// MaxDuration is the longest a cue stays on screen. Defaults to
+	// DefaultMaxDuration.
+	MaxDuration time.Duration
+	// MinGap is the shortest time between two cues. Cues are ended earlier
+	// to make room for it.
+	MinGap time.Duration
+	// IgnoreParagraphs keeps filling cues across paragraph starts
+	IgnoreParagraphs bool
+	// IgnoreSpeakers keeps filling cues across speaker changes
+	IgnoreSpeakers bool
+}
+
+func (o CueOptions) withDefaults() CueOptions {
+	if o.MaxLineChars <= 0 {
+		o.MaxLineChars = DefaultMaxLineChars
+	}
+	if o.MaxLines <= 0 {
+		o.MaxLines = DefaultMaxLines
+	}
+	if o.MaxDuration <= 0 {
+		o.MaxDuration = DefaultMaxDuration
+	}
+	return o
+}
+
+// Cues lays the words of the transcript out into caption cues. A cue ends
+// where its last word does, that is where the next word starts. The cue that
+// starts a speaker turn carries the speaker.
+func (t Transcript) Cues(opts CueOptions) []captions.Cue {
+	opts = opts.withDefaults()
+	var (
+		cues []captions.Cue
+		cue  *captions.Cue
+		prev = TimedWord{Paragraph: -1}
+	)
+	flush := func() {
+		if cue != nil {
+			cues = append(cues, *cue)
+			cue = nil
+		}
+	}
+	for _, w := range t.TimedWords() {
+		if w.IsSilence() {
+			continue
+		}
+		turn := w.Speaker != "" && w.Speaker != prev.Speaker
+		if cue != nil {
+			switch {
+			case w.Paragraph != prev.Paragraph && !opts.IgnoreParagraphs,
+				turn && !opts.IgnoreSpeakers,
+				w.End-cue.Start > opts.MaxDuration,
+				!fits(cue, w.Text, opts):
+				flush()
+			}
+		}
+		if cue == nil {
+			cue = &captions.Cue{Start: w.Start}
+			if turn {
+				cue.Speaker = w.Speaker
+			}
+		}
+		addWord(cue, w.Text, opts)
+		cue.End = w.End
+		prev = w
+	}
+	flush()
+
+	for i := 0; i+1 < len(cues) && opts.MinGap > 0; i++ {
+		if latest := cues[i+1].Start - opts.MinGap; cues[i].End > latest {
+			cues[i].End = latest
+			if cues[i].End <= cues[i].Start {
+				cues[i].End = cues[i].Start + time.Millisecond
+			}
+		}
+	}
+	return cues
+}
+
+// WriteCaptions lays the transcript out into cues and writes them in SRT or
+// WebVTT
+func (t Transcript) WriteCaptions(w io.Writer, format types.CaptionsFormat, opts CueOptions) error {
+	return captions.Write(w, t.Cues(opts), format)
+}
+
+func lineLimit(cue *captions.Cue, line int, opts CueOptions) int {
+	if line == 0 && cue.Speaker != "" {
+		return opts.MaxLineChars - utf8.RuneCountInString(cue.Speaker) - 2
+	}
+	return opts.MaxLineChars
+}
+
+// fits reports whether a word fits in the cue without adding a line past
+// MaxLines
+func fits(cue *captions.Cue, text string, opts CueOptions) bool {
+	last := len(cue.Lines) - 1
+	if utf8.RuneCountInString(cue.Lines[last])+1+utf8.RuneCountInString(text) <= lineLimit(cue, last, opts) {
+		return true
+	}
+	return len(cue.Lines) < opts.MaxLines
+}
+
+func addWord(cue *captions.Cue, text string, opts CueOptions) {
+	if len(cue.Lines) == 0 {
+		cue.Lines = []string{text}
+		return
+	}
+	last := len(cue.Lines) - 1
+	if utf8.RuneCountInString(cue.Lines[last])+1+utf8.RuneCountInString(text) <= lineLimit(cue, last, opts) {
+		cue.Lines[last] += " " + text
+		return
+	}
+	cue.Lines = append(cue.Lines, text)
+}
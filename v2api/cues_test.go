@@ -0,0 +1,98 @@
+package v2api_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+)
+
+// transcriptOf builds a transcript with a word every 500ms. Empty strings are
+// silences.
+func transcriptOf(text ...string) v2api.Transcript {
+	t := v2api.Transcript{Speakers: map[string]string{}}
+	for i, w := range text {
+		t.Words = append(t.Words, v2api.Word{strconv.Itoa(i * 500), w})
+	}
+	return t
+}
+
+func TestTranscriptCues(t *testing.T) {
+	assert := assert.New(t)
+	transcript := transcriptOf("NARRATOR: This", "is", "the", "first", "paragraph.", "",
+		"It", "goes", "on", "for", "a", "while", "longer.", "", "JOHN: Hi.")
+	transcript.Paragraphs = []int{0, 3000}
+
+	cues := transcript.Cues(v2api.CueOptions{MaxLineChars: 16})
+	assert.Equal([]captions.Cue{
+		{Start: 0, End: 2000 * time.Millisecond, Speaker: "NARRATOR", Lines: []string{"This", "is the first"}},
+		{Start: 2000 * time.Millisecond, End: 2500 * time.Millisecond, Lines: []string{"paragraph."}},
+		{Start: 3000 * time.Millisecond, End: 6500 * time.Millisecond, Lines: []string{"It goes on for a", "while longer."}},
+		{Start: 7000 * time.Millisecond, End: 7500 * time.Millisecond, Speaker: "JOHN", Lines: []string{"Hi."}},
+	}, cues)
+}
+
+func TestTranscriptCuesSpeakersMap(t *testing.T) {
+	assert := assert.New(t)
+	transcript := transcriptOf("Hello", "there.", "Hi.")
+	transcript.Speakers = map[string]string{"0": "JANE", "1000": "BOB"}
+
+	cues := transcript.Cues(v2api.CueOptions{})
+	assert.Len(cues, 2)
+	assert.Equal("JANE", cues[0].Speaker)
+	assert.Equal("BOB", cues[1].Speaker)
+
+	cues = transcript.Cues(v2api.CueOptions{IgnoreSpeakers: true})
+	assert.Len(cues, 1)
+	assert.Equal([]string{"Hello there. Hi."}, cues[0].Lines)
+}
+
+func TestTranscriptCuesLimits(t *testing.T) {
+	assert := assert.New(t)
+	words := strings.Fields("one two three four five six seven eight nine ten eleven twelve")
+	transcript := transcriptOf(words...)
+
+	cues := transcript.Cues(v2api.CueOptions{MaxDuration: 2 * time.Second, MinGap: 100 * time.Millisecond})
+	assert.Len(cues, 3)
+	for i, cue := range cues {
+		assert.True(cue.End-cue.Start <= 2*time.Second)
+		if i > 0 {
+			assert.Equal(100*time.Millisecond, cue.Start-cues[i-1].End)
+		}
+	}
+
+	cues = transcript.Cues(v2api.CueOptions{MaxLineChars: 10, MaxLines: 1, MaxDuration: time.Minute})
+	for _, cue := range cues {
+		assert.Len(cue.Lines, 1)
+		assert.True(len(cue.Lines[0]) <= 10)
+	}
+
+	long := transcriptOf("incomprehensibilities")
+	assert.Equal([]string{"incomprehensibilities"}, long.Cues(v2api.CueOptions{MaxLineChars: 10})[0].Lines)
+}
+
+func TestTranscriptCuesCountCharacters(t *testing.T) {
+	// "été à noël" is 10 characters but 14 bytes
+	transcript := transcriptOf("ANNE: été", "à", "noël")
+	cues := transcript.Cues(v2api.CueOptions{MaxLineChars: 16})
+	assert.Equal(t, []string{"été à noël"}, cues[0].Lines)
+	assert.Equal(t, "ANNE", cues[0].Speaker)
+}
+
+func TestTranscriptWriteCaptions(t *testing.T) {
+	assert := assert.New(t)
+	transcript := transcriptOf("NARRATOR: Hello", "world.")
+	var b bytes.Buffer
+	assert.Nil(transcript.WriteCaptions(&b, types.SRT, v2api.CueOptions{}))
+	assert.Equal("1\n00:00:00,000 --> 00:00:01,000\nNARRATOR: Hello world.\n\n", b.String())
+
+	b.Reset()
+	assert.Nil(transcript.WriteCaptions(&b, types.WebVTT, v2api.CueOptions{}))
+	assert.Equal("WEBVTT\n\n00:00:00.000 --> 00:00:01.000\n<v NARRATOR>Hello world.\n\n", b.String())
+}
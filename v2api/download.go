@@ -0,0 +1,57 @@
+package v2api
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"github.com/nytimes/threeplay/internal/transport"
+)
+
+// DefaultMaxDownloadSize is the size above which downloads of captions and
+// transcripts fail with ErrBodyTooLarge, unless changed with
+// WithMaxDownloadSize
+const DefaultMaxDownloadSize = 1 << 30
+
+// ErrBodyTooLarge is returned when a response or download exceeds the
+// maximum size set on the client
+var ErrBodyTooLarge = transport.ErrBodyTooLarge
+
+// errorPrefixSize bounds the bytes of a download checked for an API error.
+// Errors are small: bodies that don't end within it are never errors.
+const errorPrefixSize = 64 << 10
+
+// WithMaxDownloadSize sets the size above which downloads of captions and
+// transcripts fail with ErrBodyTooLarge. A size of 0 or less removes the
+// cap.
+func WithMaxDownloadSize(size int64) Option {
+	return func(c *Client) {
+		c.maxDownload = size
+	}
+}
+
+// download streams the body returned by endpoint to w, failing when it's an
+// API error
+func (c *Client) download(ctx context.Context, w io.Writer, endpoint string) (int64, error) {
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return 0, err
+	}
+	defer transport.CloseBody(res)
+
+	body := bufio.NewReaderSize(res.Body, errorPrefixSize)
+	prefix, err := body.Peek(errorPrefixSize)
+	switch err {
+	case nil, bufio.ErrBufferFull:
+	case io.EOF:
+		if err := checkForAPIError(prefix); err != nil {
+			return 0, withResponse(err, res)
+		}
+	default:
+		return 0, err
+	}
+	if !transport.SuccessStatus(res.StatusCode) {
+		return 0, newStatusError(res, prefix)
+	}
+	return io.Copy(transport.LimitWriter(w, c.maxDownload), body)
+}
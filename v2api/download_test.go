@@ -0,0 +1,70 @@
+package v2api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestGetTranscriptWithFormatTo(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	// larger than the prefix checked for errors
+	transcript := strings.Repeat("some transcript text\n", 10000)
+	gock.New("https://static.3playmedia.com").
+		Get("/files/123456/transcript.txt").
+		MatchParam("apikey", "api-key").
+		Reply(200).
+		BodyString(transcript)
+
+	client := v2api.NewClient("api-key", "secret-key")
+	var buf bytes.Buffer
+	n, err := client.GetTranscriptWithFormatTo(context.Background(), &buf, 123456, v2api.TXT)
+	assert.Nil(err)
+	assert.Equal(int64(len(transcript)), n)
+	assert.Equal(transcript, buf.String())
+}
+
+func TestGetTranscriptByVideoIDWithFormatToError(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://static.3playmedia.com").
+		Get("/files/abc/transcript.txt").
+		MatchParam("apikey", "api-key").
+		MatchParam("usevideoid", "1").
+		Reply(200).
+		File("../fixtures/error.json")
+
+	client := v2api.NewClient("api-key", "secret-key")
+	var buf bytes.Buffer
+	n, err := client.GetTranscriptByVideoIDWithFormatTo(context.Background(), &buf, "abc", v2api.TXT)
+	assert.Equal(int64(0), n)
+	assert.Equal(v2api.ErrUnauthorized.Error(), err.Error())
+	assert.Empty(buf.String())
+}
+
+func TestGetCaptionsToMaxDownloadSize(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://static.3playmedia.com").
+		Get("/files/123456/captions.srt").
+		MatchParam("apikey", "api-key").
+		Reply(200).
+		BodyString("1\n00:00:00,000 --> 00:00:01,000\nHello\n")
+
+	client := v2api.NewClient("api-key", "secret-key", v2api.WithMaxDownloadSize(10))
+	var buf bytes.Buffer
+	n, err := client.GetCaptionsTo(context.Background(), &buf, v2api.GetCaptionsOptions{FileID: 123456, Format: "srt"})
+	assert.True(errors.Is(err, v2api.ErrBodyTooLarge))
+	assert.Equal(int64(10), n)
+	assert.Equal("1\n00:00:00", buf.String())
+}
@@ -0,0 +1,110 @@
+package v2api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/nytimes/threeplay/internal/transport"
+)
+
+// ErrorTypeHTTPStatus is the Type of the *APIError returned for responses
+// with an error status whose body isn't a 3Play error, e.g. a page served by
+// a proxy. Its Message quotes the start of the body.
+const ErrorTypeHTTPStatus = "http_status"
+
+// APIError is returned when 3Play reports an error. It matches
+// ErrUnauthorized and ErrNotFound with errors.Is, and can be unwrapped with
+// errors.As by callers that need the details of the failure.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the status 3Play reported the error with, when known
+	Code int
+	// Type is the key of the first error reported, e.g. authentication
+	Type string
+	// Message is the message associated with Type
+	Message string
+	// Errors holds every error reported in the response
+	Errors map[string]string
+	// Endpoint is the path of the endpoint that returned the error
+	Endpoint string
+
+	text string
+}
+
+func (e *APIError) Error() string {
+	return e.text
+}
+
+// Is reports whether the error matches one of the package sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized || e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound || e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+func newAPIError(apiError Error, responseData []byte) *APIError {
+	e := &APIError{
+		Errors: apiError.Errors,
+		text:   "api error: " + string(responseData),
+	}
+	if message, ok := apiError.Errors["authentication"]; ok {
+		e.Code, e.Type, e.Message = http.StatusUnauthorized, "authentication", message
+		e.text = ErrUnauthorized.Error()
+		return e
+	}
+	if message, ok := apiError.Errors["not_found"]; ok {
+		e.Code, e.Type, e.Message = http.StatusNotFound, "not_found", message
+		e.text = ErrNotFound.Error()
+		return e
+	}
+	keys := make([]string, 0, len(apiError.Errors))
+	for key := range apiError.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		e.Type, e.Message = keys[0], apiError.Errors[keys[0]]
+	}
+	return e
+}
+
+func newStatusError(res *http.Response, body []byte) error {
+	e := &APIError{
+		Code:    res.StatusCode,
+		Type:    ErrorTypeHTTPStatus,
+		Message: transport.BodySnippet(body),
+	}
+	e.text = fmt.Sprintf("unexpected HTTP status %d %s", res.StatusCode, http.StatusText(res.StatusCode))
+	if e.Message != "" {
+		e.text += ": " + e.Message
+	}
+	return withResponse(e, res)
+}
+
+// withResponse records the HTTP status and endpoint of res on err, when it
+// is an *APIError
+func withResponse(err error, res *http.Response) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.StatusCode = res.StatusCode
+		if res.Request != nil && res.Request.URL != nil {
+			apiErr.Endpoint = res.Request.URL.Path
+		}
+		transport.ReportAPIError(res, apiErr.Type)
+	}
+	return err
+}
+
+// Redact masks the API key and secret in s, a request URL or a message
+// quoting one, so it can be logged safely. Errors returned by the client are
+// already redacted.
+func Redact(s string) string {
+	return transport.Redact(s)
+}
@@ -0,0 +1,122 @@
+package v2api_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestAPIErrorIsSentinel(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/files/123456").
+		MatchParam("apikey", "api-key").
+		Reply(200).
+		File("../fixtures/error.json")
+
+	client := v2api.NewClient("api-key", "secret-key")
+	_, err := client.GetFile(123456)
+	assert.True(errors.Is(err, v2api.ErrUnauthorized))
+	assert.False(errors.Is(err, v2api.ErrNotFound))
+
+	var apiErr *v2api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(200, apiErr.StatusCode)
+	assert.Equal(401, apiErr.Code)
+	assert.Equal("authentication", apiErr.Type)
+	assert.Equal("Could not authenticate API Access", apiErr.Message)
+	assert.Equal("/files/123456", apiErr.Endpoint)
+}
+
+func TestAPIErrorGeneric(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	body := `{"iserror":true,"errors":{"state":"is invalid"}}`
+	gock.New("https://api.3playmedia.com").
+		Get("/files").
+		MatchParam("apikey", "api-key").
+		Reply(422).
+		BodyString(body)
+
+	client := v2api.NewClient("api-key", "secret-key")
+	_, err := client.GetFiles(nil, nil)
+	assert.Equal("api error: "+body, err.Error())
+	assert.False(errors.Is(err, v2api.ErrUnauthorized))
+
+	var apiErr *v2api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(422, apiErr.StatusCode)
+	assert.Equal("state", apiErr.Type)
+	assert.Equal("is invalid", apiErr.Message)
+	assert.Equal(map[string]string{"state": "is invalid"}, apiErr.Errors)
+}
+
+func TestAPIErrorHTTPStatus(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/files/123456").
+		Reply(502).
+		BodyString("<html>\n<body>Bad gateway</body>\n</html>")
+
+	client := v2api.NewClient("api-key", "secret-key", v2api.WithRetryPolicy(v2api.RetryPolicy{MaxAttempts: 1}))
+	file, err := client.GetFile(123456)
+	assert.Nil(file)
+	assert.Equal("unexpected HTTP status 502 Bad Gateway: <html> <body>Bad gateway</body> </html>", err.Error())
+
+	var apiErr *v2api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(502, apiErr.StatusCode)
+	assert.Equal(502, apiErr.Code)
+	assert.Equal(v2api.ErrorTypeHTTPStatus, apiErr.Type)
+	assert.Equal("<html> <body>Bad gateway</body> </html>", apiErr.Message)
+	assert.Equal("/files/123456", apiErr.Endpoint)
+}
+
+func TestAPIErrorHTTPStatusDownload(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://static.3playmedia.com").
+		Get("/files/123456/transcript.txt").
+		Reply(401).
+		BodyString("Unauthorized")
+
+	client := v2api.NewClient("api-key", "secret-key")
+	data, err := client.GetTranscriptWithFormat(123456, v2api.TXT)
+	assert.Nil(data)
+	assert.True(errors.Is(err, v2api.ErrUnauthorized))
+	assert.Equal("unexpected HTTP status 401 Unauthorized: Unauthorized", err.Error())
+}
+
+func TestErrorsAreRedacted(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(nil)
+	endpoint := server.URL
+	server.Close()
+
+	client := v2api.NewClient("api-key", "secret-key", v2api.WithBaseURL(endpoint), v2api.WithStaticBaseURL(endpoint),
+		v2api.WithRetryPolicy(v2api.RetryPolicy{MaxAttempts: 1}))
+	_, err := client.GetFile(123)
+	assert.NotNil(err)
+	assert.NotContains(err.Error(), "api-key")
+	_, err = client.GetCaptionsByVideoID("123", types.SRT)
+	assert.NotContains(err.Error(), "api-key")
+	_, err = client.UploadFileFromURL("https://somewhere.com/video.mp4", url.Values{})
+	assert.NotContains(err.Error(), "secret-key")
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "https://api.3playmedia.com/files?apikey=REDACTED&page=1",
+		v2api.Redact("https://api.3playmedia.com/files?apikey=api-key&page=1"))
+}
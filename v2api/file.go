@@ -1,10 +1,10 @@
 package v2api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -53,31 +53,41 @@ type Summary struct {
 
 // UpdateFile updates a File metadata
 func (c *Client) UpdateFile(fileID uint, data url.Values) error {
+	return c.UpdateFileContext(context.Background(), fileID, data)
+}
+
+// UpdateFileContext is like UpdateFile but carries ctx through the request
+// and its retries.
+func (c *Client) UpdateFileContext(ctx context.Context, fileID uint, data url.Values) error {
 	if data == nil {
 		return errors.New("must specify new data")
 	}
-	apiURL := c.createURL(fmt.Sprintf("/files/%d", fileID))
-	data.Set("apikey", c.apiKey)
-	data.Set("api_secret_key", c.apiSecret)
-	req, err := c.createRequest(http.MethodPut, apiURL.String(), data)
+	apiURL, err := c.createURL(fmt.Sprintf("/files/%d", fileID))
 	if err != nil {
 		return err
 	}
-	response, err := c.httpClient.Do(req) //nolint:bodyclose
+	req, err := c.createRequest(ctx, http.MethodPut, apiURL, data)
 	if err != nil {
 		return err
 	}
-	responseData, err := ioutil.ReadAll(response.Body)
+	response, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
-	return checkForAPIError(responseData)
+	_, err = c.readResponse(response)
+	return err
 }
 
 // GetFiles returns a list of files, supports pagination through params
 // and filters.
 // For a full list of supported filtering parameters check http://support.3playmedia.com/hc/en-us/articles/227729828-Files-API-Methods
 func (c *Client) GetFiles(params, filters url.Values) (*FilesPage, error) {
+	return c.GetFilesContext(context.Background(), params, filters)
+}
+
+// GetFilesContext is like GetFiles but carries ctx through the request and
+// its retries.
+func (c *Client) GetFilesContext(ctx context.Context, params, filters url.Values) (*FilesPage, error) {
 	querystring := url.Values{}
 	if params != nil {
 		querystring = params
@@ -86,13 +96,15 @@ func (c *Client) GetFiles(params, filters url.Values) (*FilesPage, error) {
 		querystring.Set("q", filters.Encode())
 	}
 	filesPage := &FilesPage{}
-	url := c.createURL("/files")
-	endpoint := c.prepareURL(url, querystring)
-	res, err := c.httpClient.Get(endpoint)
+	endpoint, err := c.prepareURL("/files", querystring)
 	if err != nil {
 		return nil, err
 	}
-	if err := parseResponse(res, filesPage); err != nil {
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.parseResponse(res, filesPage); err != nil {
 		return nil, err
 	}
 	return filesPage, nil
@@ -100,14 +112,22 @@ func (c *Client) GetFiles(params, filters url.Values) (*FilesPage, error) {
 
 // GetFile gets a single file by id
 func (c *Client) GetFile(id uint) (*File, error) {
+	return c.GetFileContext(context.Background(), id)
+}
+
+// GetFileContext is like GetFile but carries ctx through the request and its
+// retries.
+func (c *Client) GetFileContext(ctx context.Context, id uint) (*File, error) {
 	file := &File{}
-	url := c.createURL(fmt.Sprintf("/files/%d", id))
-	endpoint := c.prepareURL(url, nil)
-	res, err := c.httpClient.Get(endpoint)
+	endpoint, err := c.prepareURL(fmt.Sprintf("/files/%d", id), nil)
 	if err != nil {
 		return nil, err
 	}
-	if err := parseResponse(res, file); err != nil {
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.parseResponse(res, file); err != nil {
 		return nil, err
 	}
 	return file, nil
@@ -116,7 +136,16 @@ func (c *Client) GetFile(id uint) (*File, error) {
 // UploadFileFromURL uploads a file to threeplay using the file's URL and
 // returns the file ID.
 func (c *Client) UploadFileFromURL(fileURL string, options url.Values) (uint, error) {
-	apiURL := c.createURL("/files")
+	return c.UploadFileFromURLContext(context.Background(), fileURL, options)
+}
+
+// UploadFileFromURLContext is like UploadFileFromURL but carries ctx through
+// the request and its retries.
+func (c *Client) UploadFileFromURLContext(ctx context.Context, fileURL string, options url.Values) (uint, error) {
+	apiURL, err := c.createURL("/files")
+	if err != nil {
+		return 0, err
+	}
 	data := url.Values{}
 	data.Set("apikey", c.apiKey)
 	data.Set("api_secret_key", c.apiSecret)
@@ -124,16 +153,11 @@ func (c *Client) UploadFileFromURL(fileURL string, options url.Values) (uint, er
 	for key, val := range options {
 		data[key] = val
 	}
-	res, err := c.httpClient.PostForm(apiURL.String(), data)
-	if err != nil {
-		return 0, err
-	}
-	defer res.Body.Close()
-	responseData, err := ioutil.ReadAll(res.Body)
+	res, err := c.httpClient.PostForm(ctx, apiURL, data)
 	if err != nil {
 		return 0, err
 	}
-	err = checkForAPIError(responseData)
+	responseData, err := c.readResponse(res)
 	if err != nil {
 		return 0, err
 	}
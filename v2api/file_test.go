@@ -1,6 +1,8 @@
 package v2api_test
 
 import (
+	"context"
+	"errors"
 	"net/url"
 	"testing"
 
@@ -231,3 +233,15 @@ func TestUploadFileFromURLInvalidResponse(t *testing.T) {
 	assert.NotNil(err)
 	assert.Equal(err.Error(), "invalid response: <p>Something went wrong, but I still return 200!</p>")
 }
+
+func TestGetFileContextCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	client := v2api.NewClient("api-key", "secret-key")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	file, err := client.GetFileContext(ctx, 123456)
+	assert.Nil(file)
+	assert.True(errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,206 @@
+package v2api
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// FilesIterator walks every page of GetFiles, fetching pages as they are
+// needed:
+//
+//	it := client.IterateFiles(ctx, nil, filters)
+//	for it.Next() {
+//		file := it.File()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type FilesIterator struct {
+	client  *Client
+	ctx     context.Context
+	cancel  context.CancelFunc
+	params  url.Values
+	filters url.Values
+
+	prefetch bool
+	started  bool
+	page     int
+	next     chan pageResult
+
+	files        []File
+	index        int
+	current      File
+	summary      Summary
+	totalEntries int
+	totalPages   int
+	err          error
+	done         bool
+}
+
+type pageResult struct {
+	page *FilesPage
+	err  error
+}
+
+// IterateFiles returns an iterator over the files matching params and
+// filters, which are given the same way as to GetFiles. Iteration starts at
+// the page in params, if any, and stops when ctx is done.
+func (c *Client) IterateFiles(ctx context.Context, params, filters url.Values) *FilesIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &FilesIterator{
+		client:  c,
+		ctx:     ctx,
+		cancel:  cancel,
+		params:  url.Values{},
+		filters: filters,
+		page:    1,
+	}
+	for key, val := range params {
+		it.params[key] = val
+	}
+	if page, err := strconv.Atoi(it.params.Get("page")); err == nil && page > 0 {
+		it.page = page
+	}
+	return it
+}
+
+// Prefetch makes the iterator fetch the next page in the background while
+// the current one is being consumed. It must be called before Next.
+func (it *FilesIterator) Prefetch() *FilesIterator {
+	it.prefetch = true
+	return it
+}
+
+// Next advances to the next file, fetching the next page when needed. It
+// returns false when every file was visited or iteration failed, see Err.
+func (it *FilesIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		return it.stop(err)
+	}
+	for it.index >= len(it.files) {
+		if it.started && it.page > it.totalPages {
+			return it.stop(nil)
+		}
+		result := it.fetchPage()
+		if result.err != nil {
+			return it.stop(result.err)
+		}
+		it.started = true
+		it.files, it.index = result.page.Files, 0
+		it.summary = result.page.Summary
+		it.totalEntries = summaryInt(result.page.Summary.TotalEntries)
+		it.totalPages = summaryInt(result.page.Summary.TotalPages)
+		if len(it.files) == 0 {
+			return it.stop(nil)
+		}
+		it.page++
+		if it.prefetch && it.page <= it.totalPages {
+			it.next = make(chan pageResult, 1)
+			go func(next chan pageResult, page int) {
+				next <- it.getPage(page)
+			}(it.next, it.page)
+		}
+	}
+	it.current = it.files[it.index]
+	it.index++
+	return true
+}
+
+// File returns the file Next advanced to
+func (it *FilesIterator) File() File {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *FilesIterator) Err() error {
+	return it.err
+}
+
+// TotalEntries returns the number of files matching the query, as reported
+// with the last page fetched
+func (it *FilesIterator) TotalEntries() int {
+	return it.totalEntries
+}
+
+// Summary returns the summary of the last page fetched
+func (it *FilesIterator) Summary() Summary {
+	return it.summary
+}
+
+// Close stops the iteration and any page being prefetched
+func (it *FilesIterator) Close() {
+	it.stop(nil)
+}
+
+func (it *FilesIterator) stop(err error) bool {
+	it.done = true
+	if it.err == nil {
+		it.err = err
+	}
+	it.cancel()
+	// the prefetch returns promptly once cancelled, and mustn't outlive the
+	// iterator
+	if it.next != nil {
+		<-it.next
+		it.next = nil
+	}
+	return false
+}
+
+func (it *FilesIterator) fetchPage() pageResult {
+	if it.next != nil {
+		select {
+		case result := <-it.next:
+			it.next = nil
+			return result
+		case <-it.ctx.Done():
+			// stop waits for the prefetch
+			return pageResult{err: it.ctx.Err()}
+		}
+	}
+	return it.getPage(it.page)
+}
+
+func (it *FilesIterator) getPage(page int) pageResult {
+	params := url.Values{}
+	for key, val := range it.params {
+		params[key] = val
+	}
+	params.Set("page", strconv.Itoa(page))
+	filesPage, err := it.client.GetFilesContext(it.ctx, params, it.filters)
+	return pageResult{page: filesPage, err: err}
+}
+
+// EachFile calls fn with every file matching params and filters, which are
+// given the same way as to GetFiles. It stops at the first error returned by
+// fn or by the API.
+func (c *Client) EachFile(ctx context.Context, params, filters url.Values, fn func(File) error) error {
+	it := c.IterateFiles(ctx, params, filters)
+	defer it.Close()
+	for it.Next() {
+		if err := fn(it.File()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// summaryInt converts a summary field, which the API reports either as a
+// number or as a string, to an int. Missing values are 0.
+func summaryInt(n json.Number) int {
+	i, err := strconv.Atoi(n.String())
+	if err != nil {
+		f, err := n.Float64()
+		if err != nil {
+			return 0
+		}
+		return int(f)
+	}
+	return i
+}
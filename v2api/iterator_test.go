@@ -0,0 +1,180 @@
+package v2api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func newFakeServerWithFiles(count int) *threeplaytest.Server {
+	server := threeplaytest.NewServer()
+	for i := 0; i < count; i++ {
+		server.AddFile("file-" + strconv.Itoa(i))
+	}
+	return server
+}
+
+func TestIterateFiles(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		prefetch := prefetch
+		t.Run("prefetch "+strconv.FormatBool(prefetch), func(t *testing.T) {
+			assert := assert.New(t)
+			server := newFakeServerWithFiles(23)
+			defer server.Close()
+			client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+
+			it := client.IterateFiles(context.Background(), url.Values{"per_page": {"10"}}, nil)
+			if prefetch {
+				it.Prefetch()
+			}
+			var names []string
+			for it.Next() {
+				names = append(names, it.File().Name)
+			}
+			assert.Nil(it.Err())
+			assert.Len(names, 23)
+			assert.Equal("file-0", names[0])
+			assert.Equal("file-22", names[22])
+			assert.Equal(23, it.TotalEntries())
+			assert.Equal("3", it.Summary().CurrentPage.String())
+			assert.False(it.Next())
+		})
+	}
+}
+
+func TestIterateFilesWithFiltersAndStartPage(t *testing.T) {
+	assert := assert.New(t)
+	server := newFakeServerWithFiles(12)
+	defer server.Close()
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+
+	it := client.IterateFiles(context.Background(), url.Values{"per_page": {"5"}, "page": {"2"}}, nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(it.Err())
+	assert.Equal(7, count)
+
+	it = client.IterateFiles(context.Background(), nil, url.Values{"name": {"file-3"}})
+	assert.True(it.Next())
+	assert.Equal("file-3", it.File().Name)
+	assert.False(it.Next())
+	assert.Equal(1, it.TotalEntries())
+}
+
+func TestIterateFilesStopsOnCancel(t *testing.T) {
+	assert := assert.New(t)
+	server := newFakeServerWithFiles(30)
+	defer server.Close()
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := client.IterateFiles(ctx, nil, nil).Prefetch()
+	count := 0
+	for it.Next() {
+		count++
+		if count == 5 {
+			cancel()
+		}
+	}
+	assert.Equal(5, count)
+	assert.True(errors.Is(it.Err(), context.Canceled))
+}
+
+// slowPages holds the requests for pages after the first until they're
+// cancelled, and counts those still running
+type slowPages struct {
+	inFlight int32
+	started  chan struct{}
+}
+
+func (s *slowPages) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("page") == "1" {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	s.started <- struct{}{}
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestIterateFilesCloseWaitsForPrefetch(t *testing.T) {
+	assert := assert.New(t)
+	server := newFakeServerWithFiles(30)
+	defer server.Close()
+	transport := &slowPages{started: make(chan struct{}, 1)}
+	client := v2api.NewClientWithHTTPClient(server.APIKey, server.APISecret, &http.Client{Transport: transport},
+		server.V2Options()...)
+
+	it := client.IterateFiles(context.Background(), nil, nil).Prefetch()
+	assert.True(it.Next())
+	<-transport.started
+	it.Close()
+	assert.Equal(int32(0), atomic.LoadInt32(&transport.inFlight))
+	assert.False(it.Next())
+}
+
+func TestIterateFilesAPIError(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/files").
+		MatchParam("page", "1").
+		Reply(200).
+		File("../fixtures/files_page1.json")
+	gock.New("https://api.3playmedia.com").
+		Get("/files").
+		MatchParam("page", "2").
+		Reply(200).
+		File("../fixtures/error.json")
+
+	client := v2api.NewClient("api-key", "secret-key")
+	it := client.IterateFiles(context.Background(), nil, nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(10, count)
+	assert.Equal(33, it.TotalEntries())
+	assert.True(errors.Is(it.Err(), v2api.ErrUnauthorized))
+}
+
+func TestEachFile(t *testing.T) {
+	assert := assert.New(t)
+	server := newFakeServerWithFiles(15)
+	defer server.Close()
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+
+	count := 0
+	err := client.EachFile(context.Background(), nil, nil, func(v2api.File) error {
+		count++
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(15, count)
+
+	stop := errors.New("stop")
+	count = 0
+	err = client.EachFile(context.Background(), nil, nil, func(v2api.File) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(stop, err)
+	assert.Equal(3, count)
+}
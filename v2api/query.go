@@ -0,0 +1,168 @@
+package v2api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// FileState is the processing state of a file
+type FileState string
+
+// File states accepted by FileQuery
+const (
+	FileStateInProgress FileState = "in_progress"
+	FileStateComplete   FileState = "complete"
+	FileStateDelivered  FileState = "delivered"
+	FileStateCancelled  FileState = "cancelled"
+	FileStateError      FileState = "error"
+)
+
+// FileSortField is a field files can be sorted by
+type FileSortField string
+
+// Sort fields accepted by FileQuery
+const (
+	SortByCreatedAt FileSortField = "created_at"
+	SortByUpdatedAt FileSortField = "updated_at"
+	SortByName      FileSortField = "name"
+	SortByDuration  FileSortField = "duration"
+)
+
+// QueryTimeFormat is the format of the date filters, the same the API reports
+// timestamps with
+const QueryTimeFormat = "2006-01-02T15:04:05.000-07:00"
+
+// MaxPerPage is the largest page size accepted by FileQuery
+const MaxPerPage = 1000
+
+// FileQuery is a typed query of the files list. The zero value matches every
+// file with the API default paging.
+type FileQuery struct {
+	State      FileState
+	BatchID    uint
+	VideoID    string
+	Name       string
+	Attribute1 string
+	Attribute2 string
+	Attribute3 string
+
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	// SortBy sorts the files by a field, ascending unless Descending is set
+	SortBy     FileSortField
+	Descending bool
+
+	Page    int
+	PerPage int
+}
+
+// Validate checks the query for invalid values and combinations
+func (q FileQuery) Validate() error {
+	switch q.State {
+	case "", FileStateInProgress, FileStateComplete, FileStateDelivered, FileStateCancelled, FileStateError:
+	default:
+		return fmt.Errorf("invalid file query: unknown state %q", q.State)
+	}
+	switch q.SortBy {
+	case "", SortByCreatedAt, SortByUpdatedAt, SortByName, SortByDuration:
+	default:
+		return fmt.Errorf("invalid file query: unknown sort field %q", q.SortBy)
+	}
+	if q.Descending && q.SortBy == "" {
+		return errors.New("invalid file query: Descending requires SortBy")
+	}
+	if !q.CreatedAfter.IsZero() && !q.CreatedBefore.IsZero() && !q.CreatedAfter.Before(q.CreatedBefore) {
+		return errors.New("invalid file query: CreatedAfter must be before CreatedBefore")
+	}
+	if !q.UpdatedAfter.IsZero() && !q.UpdatedBefore.IsZero() && !q.UpdatedAfter.Before(q.UpdatedBefore) {
+		return errors.New("invalid file query: UpdatedAfter must be before UpdatedBefore")
+	}
+	if !q.UpdatedBefore.IsZero() && !q.CreatedAfter.IsZero() && q.UpdatedBefore.Before(q.CreatedAfter) {
+		return errors.New("invalid file query: UpdatedBefore can't be before CreatedAfter")
+	}
+	if q.Page < 0 {
+		return errors.New("invalid file query: Page can't be negative")
+	}
+	if q.PerPage < 0 || q.PerPage > MaxPerPage {
+		return fmt.Errorf("invalid file query: PerPage must be between 1 and %d", MaxPerPage)
+	}
+	return nil
+}
+
+// Encode validates the query and returns the params and filters to give to
+// GetFiles
+func (q FileQuery) Encode() (params, filters url.Values, err error) {
+	if err := q.Validate(); err != nil {
+		return nil, nil, err
+	}
+	params, filters = url.Values{}, url.Values{}
+	set := func(values url.Values, key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	setTime := func(key string, t time.Time) {
+		if !t.IsZero() {
+			filters.Set(key, t.Format(QueryTimeFormat))
+		}
+	}
+
+	set(filters, "state", string(q.State))
+	if q.BatchID != 0 {
+		filters.Set("batch_id", strconv.FormatUint(uint64(q.BatchID), 10))
+	}
+	set(filters, "video_id", q.VideoID)
+	set(filters, "name", q.Name)
+	set(filters, "attribute1", q.Attribute1)
+	set(filters, "attribute2", q.Attribute2)
+	set(filters, "attribute3", q.Attribute3)
+	setTime("created_after", q.CreatedAfter)
+	setTime("created_before", q.CreatedBefore)
+	setTime("updated_after", q.UpdatedAfter)
+	setTime("updated_before", q.UpdatedBefore)
+
+	if q.SortBy != "" {
+		params.Set("sort_by", string(q.SortBy))
+		params.Set("sort_direction", "asc")
+		if q.Descending {
+			params.Set("sort_direction", "desc")
+		}
+	}
+	if q.Page > 0 {
+		params.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.PerPage > 0 {
+		params.Set("per_page", strconv.Itoa(q.PerPage))
+	}
+	if len(filters) == 0 {
+		filters = nil
+	}
+	return params, filters, nil
+}
+
+// QueryFiles returns the page of files matching the query. Invalid queries
+// are reported without calling the API.
+func (c *Client) QueryFiles(ctx context.Context, q FileQuery) (*FilesPage, error) {
+	params, filters, err := q.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetFilesContext(ctx, params, filters)
+}
+
+// IterateQuery returns an iterator over every file matching the query,
+// starting at its page. Invalid queries are reported without calling the API.
+func (c *Client) IterateQuery(ctx context.Context, q FileQuery) (*FilesIterator, error) {
+	params, filters, err := q.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return c.IterateFiles(ctx, params, filters), nil
+}
@@ -0,0 +1,122 @@
+package v2api_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestFileQueryEncode(t *testing.T) {
+	assert := assert.New(t)
+	est := time.FixedZone("EST", -5*60*60)
+	q := v2api.FileQuery{
+		State:        v2api.FileStateComplete,
+		BatchID:      12,
+		VideoID:      "abc",
+		Attribute2:   "news",
+		CreatedAfter: time.Date(2020, 1, 2, 3, 4, 5, 0, est),
+		SortBy:       v2api.SortByCreatedAt,
+		Descending:   true,
+		Page:         3,
+		PerPage:      50,
+	}
+	params, filters, err := q.Encode()
+	assert.Nil(err)
+	assert.Equal(url.Values{
+		"sort_by":        {"created_at"},
+		"sort_direction": {"desc"},
+		"page":           {"3"},
+		"per_page":       {"50"},
+	}, params)
+	assert.Equal(url.Values{
+		"state":         {"complete"},
+		"batch_id":      {"12"},
+		"video_id":      {"abc"},
+		"attribute2":    {"news"},
+		"created_after": {"2020-01-02T03:04:05.000-05:00"},
+	}, filters)
+}
+
+func TestFileQueryEncodeZeroValue(t *testing.T) {
+	assert := assert.New(t)
+	params, filters, err := v2api.FileQuery{}.Encode()
+	assert.Nil(err)
+	assert.Empty(params)
+	assert.Nil(filters)
+}
+
+func TestFileQueryValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		query v2api.FileQuery
+		err   string
+	}{
+		{"unknown state", v2api.FileQuery{State: "done"}, `invalid file query: unknown state "done"`},
+		{"unknown sort field", v2api.FileQuery{SortBy: "size"}, `invalid file query: unknown sort field "size"`},
+		{"descending without sort", v2api.FileQuery{Descending: true}, "invalid file query: Descending requires SortBy"},
+		{
+			"inverted created range",
+			v2api.FileQuery{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)},
+			"invalid file query: CreatedAfter must be before CreatedBefore",
+		},
+		{
+			"inverted updated range",
+			v2api.FileQuery{UpdatedAfter: now, UpdatedBefore: now},
+			"invalid file query: UpdatedAfter must be before UpdatedBefore",
+		},
+		{
+			"updated before created",
+			v2api.FileQuery{CreatedAfter: now, UpdatedBefore: now.Add(-time.Hour)},
+			"invalid file query: UpdatedBefore can't be before CreatedAfter",
+		},
+		{"negative page", v2api.FileQuery{Page: -1}, "invalid file query: Page can't be negative"},
+		{"per page too large", v2api.FileQuery{PerPage: 1001}, "invalid file query: PerPage must be between 1 and 1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestQueryFiles(t *testing.T) {
+	assert := assert.New(t)
+	server := newFakeServerWithFiles(12)
+	defer server.Close()
+	client := v2api.NewClient(server.APIKey, server.APISecret, server.V2Options()...)
+
+	page, err := client.QueryFiles(context.Background(), v2api.FileQuery{Name: "file-7"})
+	assert.Nil(err)
+	assert.Len(page.Files, 1)
+	assert.Equal("file-7", page.Files[0].Name)
+
+	it, err := client.IterateQuery(context.Background(), v2api.FileQuery{PerPage: 5, Page: 2})
+	assert.Nil(err)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Nil(it.Err())
+	assert.Equal(7, count)
+}
+
+func TestQueryFilesInvalidQueryMakesNoRequest(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+	gock.New("https://api.3playmedia.com").Get("/files").Reply(200).JSON(map[string]interface{}{})
+	client := v2api.NewClient("api-key", "secret-key")
+
+	_, err := client.QueryFiles(context.Background(), v2api.FileQuery{State: "bogus"})
+	assert.EqualError(err, `invalid file query: unknown state "bogus"`)
+	it, err := client.IterateQuery(context.Background(), v2api.FileQuery{PerPage: -1})
+	assert.Nil(it)
+	assert.NotNil(err)
+	assert.True(gock.IsPending())
+}
@@ -0,0 +1,14 @@
+package v2api
+
+import "github.com/nytimes/threeplay/ratelimit"
+
+// WithRateLimiter makes every attempt of every call wait for the budget of
+// its API key in limiter. Captions and transcript downloads take from the
+// budget of the static host. Retries wait too, so throttled calls back off
+// instead of adding to the overload. A limiter can be shared between
+// clients, to give them a common budget.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.httpClient.Limiter = limiter
+	}
+}
@@ -0,0 +1,36 @@
+package v2api_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/ratelimit"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	id := uint(server.AddFile("video.mp4"))
+
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 0.001, Burst: 1})
+	client := v2api.NewClient(server.APIKey, server.APISecret, append(server.V2Options(), v2api.WithRateLimiter(limiter))...)
+
+	_, err := client.GetFileContext(context.Background(), id)
+	assert.Nil(err)
+	// downloads take from the budget of the static host
+	_, err = client.GetCaptionsContext(context.Background(), v2api.GetCaptionsOptions{FileID: id, Format: types.SRT})
+	assert.NotEqual(context.DeadlineExceeded, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.GetFileContext(ctx, id)
+	assert.Equal(context.DeadlineExceeded, err)
+	_, err = client.GetCaptionsContext(ctx, v2api.GetCaptionsOptions{FileID: id, Format: types.SRT})
+	assert.Equal(context.DeadlineExceeded, err)
+}
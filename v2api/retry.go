@@ -0,0 +1,40 @@
+package v2api
+
+import (
+	"time"
+
+	"github.com/nytimes/threeplay/internal/transport"
+)
+
+// RetryPolicy configures how failed calls are retried. The zero value uses
+// the defaults: up to 5 attempts with exponential backoff, honoring
+// Retry-After up to a minute. Idempotent calls are retried on network errors,
+// 429 and 5xx responses. Calls such as uploads and orders are only retried
+// when the server provably didn't process them: on 429 responses and when the
+// connection couldn't be established.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts made for a call, the first one
+	// included. 1 disables retries.
+	MaxAttempts int
+	// Backoff is the wait before the given retry, when the server doesn't
+	// send Retry-After
+	Backoff func(retry int) time.Duration
+	// MaxRetryAfter caps the Retry-After waits. Calls asked to wait longer
+	// fail with the server's error.
+	MaxRetryAfter time.Duration
+	// RetryNonIdempotent retries every call the same way, at the risk of
+	// duplicating uploads and orders
+	RetryNonIdempotent bool
+}
+
+// WithRetryPolicy sets how failed calls are retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.httpClient.Policy = transport.Policy{
+			MaxAttempts:        policy.MaxAttempts,
+			Backoff:            policy.Backoff,
+			MaxRetryAfter:      policy.MaxRetryAfter,
+			RetryNonIdempotent: policy.RetryNonIdempotent,
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package v2api_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+var noBackoff = v2api.WithRetryPolicy(v2api.RetryPolicy{
+	Backoff: func(int) time.Duration { return 0 },
+})
+
+func TestAddTagRetriesServerErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	defer gock.Off()
+	gock.New("https://api.3playmedia.com").
+		Post("/files/123456/tags").
+		Reply(500)
+	gock.New("https://api.3playmedia.com").
+		Post("/files/123456/tags").
+		Reply(200).
+		BodyString(`{"result":true,"media_file_tags":["physics"]}`)
+
+	client := v2api.NewClient("api-key", "secret-key", noBackoff)
+	tags, err := client.AddTag(123456, "physics")
+	assert.Nil(err)
+	assert.Equal([]string{"physics"}, tags)
+	assert.True(gock.IsDone())
+}
+
+func TestUploadIsNotRetriedOnServerErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	defer gock.Off()
+	gock.New("https://api.3playmedia.com").
+		Post("/files").
+		Times(1).
+		Reply(500).
+		BodyString("Internal Server Error")
+
+	client := v2api.NewClient("api-key", "secret-key", noBackoff)
+	_, err := client.UploadFileFromURL("https://somewhere.com/video.mp4", url.Values{})
+	assert.NotNil(err)
+	assert.True(gock.IsDone())
+}
+
+func TestUploadIsRetriedWhenThrottled(t *testing.T) {
+	assert := assert.New(t)
+
+	defer gock.Off()
+	gock.New("https://api.3playmedia.com").
+		Post("/files").
+		Reply(429).
+		SetHeader("Retry-After", "0")
+	gock.New("https://api.3playmedia.com").
+		Post("/files").
+		Reply(200).
+		BodyString("1686514")
+
+	client := v2api.NewClient("api-key", "secret-key", noBackoff)
+	id, err := client.UploadFileFromURL("https://somewhere.com/video.mp4", url.Values{})
+	assert.Nil(err)
+	assert.Equal(uint(1686514), id)
+	assert.True(gock.IsDone())
+}
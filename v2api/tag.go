@@ -1,23 +1,33 @@
 package v2api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
 
-	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/internal/transport"
 )
 
 // GetTags gets the list of tags of a file
 func (c *Client) GetTags(fileID uint) ([]string, error) {
-	endpoint := fmt.Sprintf("https://%s/files/%d/tags?apikey=%s", types.ThreePlayHost, fileID, c.apiKey)
-	response, err := c.httpClient.Get(endpoint)
+	return c.GetTagsContext(context.Background(), fileID)
+}
+
+// GetTagsContext is like GetTags but carries ctx through the request and its
+// retries.
+func (c *Client) GetTagsContext(ctx context.Context, fileID uint) ([]string, error) {
+	endpoint, err := c.prepareURL(fmt.Sprintf("/files/%d/tags", fileID), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.httpClient.Get(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
 
 	var tags []string
-	if err := parseResponse(response, &tags); err != nil {
+	if err := c.parseResponse(response, &tags); err != nil {
 		return nil, err
 	}
 
@@ -31,20 +41,30 @@ type addTagResult struct {
 
 // AddTag adds a tag to a file
 func (c *Client) AddTag(fileID uint, tag string) ([]string, error) {
-	endpoint := fmt.Sprintf("https://%s/files/%d/tags", types.ThreePlayHost, fileID)
+	return c.AddTagContext(context.Background(), fileID, tag)
+}
+
+// AddTagContext is like AddTag but carries ctx through the request and its
+// retries.
+func (c *Client) AddTagContext(ctx context.Context, fileID uint, tag string) ([]string, error) {
+	endpoint, err := c.createURL(fmt.Sprintf("/files/%d/tags", fileID))
+	if err != nil {
+		return nil, err
+	}
 
 	data := url.Values{}
 	data.Set("apikey", c.apiKey)
 	data.Set("api_secret_key", c.apiSecret)
 	data.Set("name", tag)
 
-	response, err := c.httpClient.PostForm(endpoint, data)
+	// adding a tag twice leaves the same tags
+	response, err := c.httpClient.PostForm(transport.MarkIdempotent(ctx), endpoint, data)
 	if err != nil {
 		return nil, err
 	}
 
 	result := &addTagResult{}
-	if err := parseResponse(response, result); err != nil {
+	if err := c.parseResponse(response, result); err != nil {
 		return nil, err
 	}
 
@@ -57,20 +77,30 @@ func (c *Client) AddTag(fileID uint, tag string) ([]string, error) {
 
 // RemoveTag removes a tag of a file
 func (c *Client) RemoveTag(fileID uint, tag string) ([]string, error) {
-	endpoint := fmt.Sprintf("https://%s/files/%d/tags/%s", types.ThreePlayHost, fileID, tag)
+	return c.RemoveTagContext(context.Background(), fileID, tag)
+}
+
+// RemoveTagContext is like RemoveTag but carries ctx through the request and
+// its retries.
+func (c *Client) RemoveTagContext(ctx context.Context, fileID uint, tag string) ([]string, error) {
+	endpoint, err := c.createURL(fmt.Sprintf("/files/%d/tags/%s", fileID, tag))
+	if err != nil {
+		return nil, err
+	}
 
 	data := url.Values{}
 	data.Set("apikey", c.apiKey)
 	data.Set("api_secret_key", c.apiSecret)
 	data.Set("_method", "delete")
 
-	response, err := c.httpClient.PostForm(endpoint, data)
+	// removing a tag twice leaves the same tags
+	response, err := c.httpClient.PostForm(transport.MarkIdempotent(ctx), endpoint, data)
 	if err != nil {
 		return nil, err
 	}
 
 	var tags []string
-	if err := parseResponse(response, &tags); err != nil {
+	if err := c.parseResponse(response, &tags); err != nil {
 		return nil, err
 	}
 
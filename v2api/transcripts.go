@@ -1,11 +1,14 @@
 package v2api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net/url"
 
-	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/internal/transport"
 )
 
 // TranscriptFormat supported output formats for transcripts
@@ -32,7 +35,13 @@ type Transcript struct {
 
 // GetTranscript get json transcript by file ID
 func (c *Client) GetTranscript(fileID uint) (*Transcript, error) {
-	response, err := c.GetTranscriptWithFormat(fileID, JSON)
+	return c.GetTranscriptContext(context.Background(), fileID)
+}
+
+// GetTranscriptContext is like GetTranscript but carries ctx through the
+// request and its retries.
+func (c *Client) GetTranscriptContext(ctx context.Context, fileID uint) (*Transcript, error) {
+	response, err := c.GetTranscriptWithFormatContext(ctx, fileID, JSON)
 	if err != nil {
 		return nil, err
 	}
@@ -49,30 +58,39 @@ func (c *Client) GetTranscript(fileID uint) (*Transcript, error) {
 // GetTranscriptWithFormat get transcript by file ID with supported formats
 // current supported formats are json, text and html
 func (c *Client) GetTranscriptWithFormat(id uint, format TranscriptFormat) ([]byte, error) {
-	endpoint := fmt.Sprintf("https://%s/files/%d/transcript.%s?apikey=%s",
-		types.ThreePlayStaticHost, id, format, c.apiKey,
-	)
+	return c.GetTranscriptWithFormatContext(context.Background(), id, format)
+}
 
-	response, err := c.httpClient.Get(endpoint)
-	if err != nil {
+// GetTranscriptWithFormatContext is like GetTranscriptWithFormat but carries
+// ctx through the request and its retries.
+func (c *Client) GetTranscriptWithFormatContext(ctx context.Context, id uint, format TranscriptFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := c.GetTranscriptWithFormatTo(ctx, &buf, id, format); err != nil {
 		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	responseData, err := ioutil.ReadAll(response.Body)
+// GetTranscriptWithFormatTo is like GetTranscriptWithFormatContext but streams
+// the transcript to w and returns the number of bytes written. Transcripts
+// larger than the client's maximum download size fail with ErrBodyTooLarge.
+func (c *Client) GetTranscriptWithFormatTo(ctx context.Context, w io.Writer, id uint, format TranscriptFormat) (int64, error) {
+	endpoint, err := c.prepareStaticURL(fmt.Sprintf("/files/%d/transcript.%s", id, format), nil)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	if err := checkForAPIError(responseData); err != nil {
-		return nil, err
-	}
-
-	return responseData, nil
+	return c.download(transport.MarkStatic(ctx), w, endpoint)
 }
 
 // GetTranscriptByVideoID get json transcript by video ID
 func (c *Client) GetTranscriptByVideoID(videoID string) (*Transcript, error) {
-	response, err := c.GetTranscriptByVideoIDWithFormat(videoID, JSON)
+	return c.GetTranscriptByVideoIDContext(context.Background(), videoID)
+}
+
+// GetTranscriptByVideoIDContext is like GetTranscriptByVideoID but carries ctx
+// through the request and its retries.
+func (c *Client) GetTranscriptByVideoIDContext(ctx context.Context, videoID string) (*Transcript, error) {
+	response, err := c.GetTranscriptByVideoIDWithFormatContext(ctx, videoID, JSON)
 	if err != nil {
 		return nil, err
 	}
@@ -88,22 +106,30 @@ func (c *Client) GetTranscriptByVideoID(videoID string) (*Transcript, error) {
 // GetTranscriptByVideoIDWithFormat get transcript by video ID with specific format
 // current supported formats are json, text and html
 func (c *Client) GetTranscriptByVideoIDWithFormat(id string, format TranscriptFormat) ([]byte, error) {
-	endpoint := fmt.Sprintf("https://%s/files/%s/transcript.%s?apikey=%s&usevideoid=1",
-		types.ThreePlayStaticHost, id, format, c.apiKey,
-	)
+	return c.GetTranscriptByVideoIDWithFormatContext(context.Background(), id, format)
+}
 
-	response, err := c.httpClient.Get(endpoint)
-	if err != nil {
+// GetTranscriptByVideoIDWithFormatContext is like
+// GetTranscriptByVideoIDWithFormat but carries ctx through the request and its
+// retries.
+func (c *Client) GetTranscriptByVideoIDWithFormatContext(ctx context.Context, id string, format TranscriptFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := c.GetTranscriptByVideoIDWithFormatTo(ctx, &buf, id, format); err != nil {
 		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	responseData, err := ioutil.ReadAll(response.Body)
+// GetTranscriptByVideoIDWithFormatTo is like
+// GetTranscriptByVideoIDWithFormatContext but streams the transcript to w and
+// returns the number of bytes written. Transcripts larger than the client's
+// maximum download size fail with ErrBodyTooLarge.
+func (c *Client) GetTranscriptByVideoIDWithFormatTo(ctx context.Context, w io.Writer, id string, format TranscriptFormat) (int64, error) {
+	params := url.Values{}
+	params.Set("usevideoid", "1")
+	endpoint, err := c.prepareStaticURL(fmt.Sprintf("/files/%s/transcript.%s", id, format), params)
 	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForAPIError(responseData); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return responseData, nil
+	return c.download(transport.MarkStatic(ctx), w, endpoint)
 }
@@ -0,0 +1,137 @@
+package v2api
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// speakerPrefix matches the "NAME:" label of the first word of a turn
+var speakerPrefix = regexp.MustCompile(`^([A-Z][A-Z0-9 .'\-]*):\s*`)
+
+// Start is when the word starts, from the beginning of the media
+func (w Word) Start() time.Duration {
+	ms, _ := strconv.ParseInt(strings.TrimSpace(w[0]), 10, 64)
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Text is the text of the word, without its speaker label
+func (w Word) Text() string {
+	text := strings.TrimSpace(w[1])
+	if m := speakerPrefix.FindStringIndex(text); m != nil {
+		return text[m[1]:]
+	}
+	return text
+}
+
+// Label is the speaker label in front of the word, e.g. NARRATOR for
+// "NARRATOR: This", or empty if there's none
+func (w Word) Label() string {
+	if m := speakerPrefix.FindStringSubmatch(strings.TrimSpace(w[1])); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// IsSilence reports whether the word has no text. Silences mark pauses and
+// the end of the word before them.
+func (w Word) IsSilence() bool {
+	return strings.TrimSpace(w[1]) == ""
+}
+
+// TimedWord is a word of a transcript with the context derived from the rest
+// of it
+type TimedWord struct {
+	// Index is the position of the word in Transcript.Words
+	Index int
+	Start time.Duration
+	// End is the start of the next word, or DefaultLastWordDuration after the
+	// start of the last one
+	End  time.Duration
+	Text string
+	// Speaker is the speaker of the turn the word belongs to, from
+	// Transcript.Speakers or the label of the first word of the turn
+	Speaker string
+	// Paragraph is the index of the paragraph in Transcript.Paragraphs the
+	// word belongs to, or -1 for words before the first one
+	Paragraph int
+}
+
+// IsSilence reports whether the word has no text
+func (w TimedWord) IsSilence() bool {
+	return w.Text == ""
+}
+
+// TimedWords returns the words of the transcript, silences included, with
+// their end, speaker and paragraph
+func (t Transcript) TimedWords() []TimedWord {
+	paragraphs := make([]time.Duration, len(t.Paragraphs))
+	for i, p := range t.Paragraphs {
+		paragraphs[i] = time.Duration(p) * time.Millisecond
+	}
+	sort.Slice(paragraphs, func(i, j int) bool { return paragraphs[i] < paragraphs[j] })
+
+	words := make([]TimedWord, len(t.Words))
+	speaker := ""
+	for i, w := range t.Words {
+		start := w.Start()
+		if s := t.Speakers[strconv.FormatInt(int64(start/time.Millisecond), 10)]; s != "" {
+			speaker = s
+		} else if label := w.Label(); label != "" {
+			speaker = label
+		}
+		end := start + DefaultLastWordDuration
+		if i+1 < len(t.Words) {
+			end = t.Words[i+1].Start()
+		}
+		words[i] = TimedWord{
+			Index:     i,
+			Start:     start,
+			End:       end,
+			Text:      w.Text(),
+			Speaker:   speaker,
+			Paragraph: sort.Search(len(paragraphs), func(p int) bool { return paragraphs[p] > start }) - 1,
+		}
+	}
+	return words
+}
+
+// Segment is a run of words by the same speaker within a paragraph
+type Segment struct {
+	Speaker   string
+	Paragraph int
+	// Start and End span the words of the segment, silences excluded
+	Start time.Duration
+	End   time.Duration
+	Words []TimedWord
+}
+
+// Text is the text of the words of the segment
+func (s Segment) Text() string {
+	text := make([]string, len(s.Words))
+	for i, w := range s.Words {
+		text[i] = w.Text
+	}
+	return strings.Join(text, " ")
+}
+
+// Segments splits the transcript at every speaker turn and paragraph start.
+// Silences are left out of the segments.
+func (t Transcript) Segments() []Segment {
+	var segments []Segment
+	for _, w := range t.TimedWords() {
+		if w.IsSilence() {
+			continue
+		}
+		last := len(segments) - 1
+		if last < 0 || segments[last].Speaker != w.Speaker || segments[last].Paragraph != w.Paragraph {
+			segments = append(segments, Segment{Speaker: w.Speaker, Paragraph: w.Paragraph, Start: w.Start})
+			last++
+		}
+		segments[last].Words = append(segments[last].Words, w)
+		segments[last].End = w.End
+	}
+	return segments
+}
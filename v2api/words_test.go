@@ -0,0 +1,65 @@
+package v2api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordAccessors(t *testing.T) {
+	assert := assert.New(t)
+	w := v2api.Word{"1500", "NARRATOR: This"}
+	assert.Equal(1500*time.Millisecond, w.Start())
+	assert.Equal("This", w.Text())
+	assert.Equal("NARRATOR", w.Label())
+	assert.False(w.IsSilence())
+
+	silence := v2api.Word{"2000", " "}
+	assert.True(silence.IsSilence())
+	assert.Equal("", silence.Text())
+	assert.Equal("", silence.Label())
+
+	plain := v2api.Word{"2500", "ratio: 3"}
+	assert.Equal("ratio: 3", plain.Text())
+	assert.Equal("", plain.Label())
+}
+
+func TestTimedWords(t *testing.T) {
+	assert := assert.New(t)
+	transcript := transcriptOf("NARRATOR: Hello", "", "there.", "Hi.")
+	transcript.Speakers = map[string]string{"1500": "BOB"}
+	transcript.Paragraphs = []int{1500, 500}
+
+	assert.Equal([]v2api.TimedWord{
+		{Index: 0, Start: 0, End: 500 * time.Millisecond, Text: "Hello", Speaker: "NARRATOR", Paragraph: -1},
+		{Index: 1, Start: 500 * time.Millisecond, End: 1000 * time.Millisecond, Speaker: "NARRATOR", Paragraph: 0},
+		{Index: 2, Start: 1000 * time.Millisecond, End: 1500 * time.Millisecond, Text: "there.", Speaker: "NARRATOR", Paragraph: 0},
+		{Index: 3, Start: 1500 * time.Millisecond, End: 2000 * time.Millisecond, Text: "Hi.", Speaker: "BOB", Paragraph: 1},
+	}, transcript.TimedWords())
+}
+
+func TestTranscriptSegments(t *testing.T) {
+	assert := assert.New(t)
+	transcript := transcriptOf("NARRATOR: This", "is", "one.", "", "Still", "narrating.", "JOHN: Hi", "there.")
+	transcript.Paragraphs = []int{0, 2000}
+
+	segments := transcript.Segments()
+	assert.Len(segments, 3)
+	assert.Equal("NARRATOR", segments[0].Speaker)
+	assert.Equal(0, segments[0].Paragraph)
+	assert.Equal("This is one.", segments[0].Text())
+	assert.Equal(time.Duration(0), segments[0].Start)
+	assert.Equal(1500*time.Millisecond, segments[0].End)
+
+	assert.Equal("NARRATOR", segments[1].Speaker)
+	assert.Equal(1, segments[1].Paragraph)
+	assert.Equal("Still narrating.", segments[1].Text())
+
+	assert.Equal("JOHN", segments[2].Speaker)
+	assert.Equal("Hi there.", segments[2].Text())
+	assert.Len(segments[2].Words, 2)
+	assert.Equal(6, segments[2].Words[0].Index)
+	assert.Equal(4000*time.Millisecond, segments[2].End)
+}
@@ -0,0 +1,141 @@
+package threeplay
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v2api"
+)
+
+// V2Service implements Service with the v2 API.
+//
+// v2 orders the transcript of a file when it's uploaded, with the account's
+// default turnaround: Order doesn't place a new order but reports the state
+// of that one. Orders can't be cancelled.
+type V2Service struct {
+	client *v2api.Client
+}
+
+var _ Service = (*V2Service)(nil)
+
+// NewV2Service returns a Service calling 3Play with client
+func NewV2Service(client *v2api.Client) *V2Service {
+	return &V2Service{client: client}
+}
+
+// Upload implements Service
+func (s *V2Service) Upload(ctx context.Context, req UploadRequest) (string, error) {
+	options := url.Values{}
+	if req.Name != "" {
+		options.Set("name", req.Name)
+	}
+	if req.ReferenceID != "" {
+		options.Set("video_id", req.ReferenceID)
+	}
+	id, err := s.client.UploadFileFromURLContext(ctx, req.SourceURL, options)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(uint64(id), 10), nil
+}
+
+// Order implements Service. The order was placed by Upload with the
+// account's defaults: opts can't change them, and ErrNotSupported is returned
+// when any is set.
+func (s *V2Service) Order(ctx context.Context, fileID string, opts OrderOptions) (*Transcript, error) {
+	if opts != (OrderOptions{}) {
+		return nil, fmt.Errorf("%w: v2 orders are placed on upload with the account's turnaround and callback", ErrNotSupported)
+	}
+	return s.Status(ctx, fileID)
+}
+
+// Status implements Service
+func (s *V2Service) Status(ctx context.Context, fileID string) (*Transcript, error) {
+	id, err := v2FileID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.client.GetFileContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Transcript{FileID: fileID, Status: v2Status(v2api.FileState(file.State))}, nil
+}
+
+// Captions implements Service
+func (s *V2Service) Captions(ctx context.Context, fileID string, format types.CaptionsFormat) ([]byte, error) {
+	id, err := v2FileID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.GetCaptionsContext(ctx, v2api.GetCaptionsOptions{FileID: id, Format: format})
+}
+
+// TranscriptText implements Service
+func (s *V2Service) TranscriptText(ctx context.Context, fileID string) (string, error) {
+	id, err := v2FileID(fileID)
+	if err != nil {
+		return "", err
+	}
+	data, err := s.client.GetTranscriptWithFormatContext(ctx, id, v2api.TXT)
+	return string(data), err
+}
+
+// Cancel implements Service. v2 orders can't be cancelled, it always
+// returns ErrNotSupported.
+func (s *V2Service) Cancel(ctx context.Context, fileID string) error {
+	return fmt.Errorf("%w: cancelling v2 orders", ErrNotSupported)
+}
+
+// Tags implements Service
+func (s *V2Service) Tags(ctx context.Context, fileID string) ([]string, error) {
+	id, err := v2FileID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.GetTagsContext(ctx, id)
+}
+
+// AddTag implements Service
+func (s *V2Service) AddTag(ctx context.Context, fileID, tag string) ([]string, error) {
+	id, err := v2FileID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.AddTagContext(ctx, id, tag)
+}
+
+// RemoveTag implements Service
+func (s *V2Service) RemoveTag(ctx context.Context, fileID, tag string) ([]string, error) {
+	id, err := v2FileID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.RemoveTagContext(ctx, id, tag)
+}
+
+func v2FileID(fileID string) (uint, error) {
+	id, err := strconv.ParseUint(fileID, 10, 0)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid file ID %q", fileID)
+	}
+	return uint(id), nil
+}
+
+// v2Status maps the state of a v2 file to the status of its transcript
+func v2Status(state v2api.FileState) Status {
+	switch state {
+	case v2api.FileStateComplete, v2api.FileStateDelivered:
+		return StatusComplete
+	case v2api.FileStateCancelled:
+		return StatusCancelled
+	case v2api.FileStateError:
+		return StatusFailed
+	case v2api.FileStateInProgress:
+		return StatusInProgress
+	}
+	return Status(state)
+}
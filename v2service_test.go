@@ -0,0 +1,45 @@
+package threeplay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nytimes/threeplay"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v2api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestV2Service(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	svc := threeplay.NewV2Service(v2api.NewClient(server.APIKey, threeplaytest.DefaultAPISecret, server.V2Options()...))
+	ctx := context.Background()
+
+	fileID := exerciseService(t, server, svc)
+
+	tags, err := svc.AddTag(ctx, fileID, "news")
+	assert.Nil(err)
+	assert.Equal([]string{"news"}, tags)
+	tags, err = svc.Tags(ctx, fileID)
+	assert.Nil(err)
+	assert.Equal([]string{"news"}, tags)
+	tags, err = svc.RemoveTag(ctx, fileID, "news")
+	assert.Nil(err)
+	assert.Empty(tags)
+
+	assert.True(errors.Is(svc.Cancel(ctx, fileID), threeplay.ErrNotSupported))
+	for _, opts := range []threeplay.OrderOptions{
+		{Turnaround: "rush"},
+		{Turnaround: threeplay.TurnaroundASR},
+		{CallbackURL: "https://example.com/callback"},
+	} {
+		transcript, err := svc.Order(ctx, fileID, opts)
+		assert.Nil(transcript)
+		assert.True(errors.Is(err, threeplay.ErrNotSupported), "%+v", opts)
+	}
+	_, err = svc.Status(ctx, "not-a-number")
+	assert.EqualError(err, `invalid file ID "not-a-number"`)
+}
@@ -0,0 +1,120 @@
+// Package callback receives the callbacks 3Play sends to the callbackURL
+// given to v3api.Client.OrderTranscript.
+//
+// Callback URLs are signed with an HMAC over their query string, so the
+// Receiver only accepts requests for URLs built by SignURL with the same
+// secret:
+//
+//	callbackURL, err := callback.SignURL("https://example.com/3play", secret, mediaFileID)
+//	...
+//	client.OrderTranscript(mediaFileID, callbackURL, "asr", v3api.CallParams{})
+//	...
+//	http.Handle("/3play", callback.NewReceiver(secret, handler))
+package callback
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query string parameters added by SignURL
+const (
+	ParamMediaFileID = "media_file_id"
+	ParamNonce       = "nonce"
+	ParamTimestamp   = "ts"
+	ParamSignature   = "signature"
+)
+
+var (
+	// ErrInvalidSignature is reported when the callback URL isn't signed
+	// with the receiver secret
+	ErrInvalidSignature = errors.New("callback: invalid signature")
+	// ErrExpired is reported when the callback URL is older than the
+	// receiver max age
+	ErrExpired = errors.New("callback: signed URL expired")
+	// ErrReplayed is reported when the same callback was already delivered
+	ErrReplayed = errors.New("callback: replayed delivery")
+)
+
+// Handler handles the events delivered to a Receiver. Returning an error
+// responds with a 500 status so that 3Play delivers the callback again.
+type Handler interface {
+	HandleEvent(ctx context.Context, event *Event) error
+}
+
+// HandlerFunc adapts a function to the Handler interface
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// HandleEvent calls f(ctx, event)
+func (f HandlerFunc) HandleEvent(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// SignURL adds the media file ID, a random nonce, the current time and an
+// HMAC-SHA256 signature of them to callbackURL. Other query parameters
+// already in callbackURL are covered by the signature too.
+func SignURL(callbackURL string, secret []byte, mediaFileID string) (string, error) {
+	return signURL(callbackURL, secret, mediaFileID, time.Now())
+}
+
+func signURL(callbackURL string, secret []byte, mediaFileID string, now time.Time) (string, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(ParamMediaFileID, mediaFileID)
+	query.Set(ParamNonce, hex.EncodeToString(nonce))
+	query.Set(ParamTimestamp, strconv.FormatInt(now.Unix(), 10))
+	query.Del(ParamSignature)
+	query.Set(ParamSignature, sign(secret, query))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// sign computes the signature of every query parameter but the signature
+func sign(secret []byte, query url.Values) string {
+	unsigned := url.Values{}
+	for key, values := range query {
+		if key != ParamSignature {
+			unsigned[key] = values
+		}
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature and, when maxAge is set, the age of a signed
+// callback query
+func verify(secret []byte, query url.Values, maxAge time.Duration, now time.Time) error {
+	signature, err := hex.DecodeString(query.Get(ParamSignature))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+	expected, _ := hex.DecodeString(sign(secret, query))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignature
+	}
+	if maxAge > 0 {
+		ts, err := strconv.ParseInt(query.Get(ParamTimestamp), 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if now.Sub(time.Unix(ts, 0)) > maxAge {
+			return ErrExpired
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package callback
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignURL(t *testing.T) {
+	assert := assert.New(t)
+	secret := []byte("secret")
+	now := time.Unix(1600000000, 0)
+
+	signed, err := signURL("https://example.com/3play?desk=metro", secret, "3633088", now)
+	assert.Nil(err)
+	u, err := url.Parse(signed)
+	assert.Nil(err)
+	query := u.Query()
+	assert.Equal("metro", query.Get("desk"))
+	assert.Equal("3633088", query.Get(ParamMediaFileID))
+	assert.Equal("1600000000", query.Get(ParamTimestamp))
+	assert.Len(query.Get(ParamNonce), 32)
+
+	assert.Nil(verify(secret, query, 0, now))
+	assert.Nil(verify(secret, query, time.Hour, now.Add(time.Minute)))
+	assert.Equal(ErrExpired, verify(secret, query, time.Hour, now.Add(2*time.Hour)))
+	assert.Equal(ErrInvalidSignature, verify([]byte("other"), query, 0, now))
+
+	query.Set(ParamMediaFileID, "1")
+	assert.Equal(ErrInvalidSignature, verify(secret, query, 0, now))
+	query.Del(ParamSignature)
+	assert.Equal(ErrInvalidSignature, verify(secret, query, 0, now))
+}
+
+func TestSignURLUsesFreshNonces(t *testing.T) {
+	first, err := SignURL("https://example.com/3play", []byte("secret"), "1")
+	assert.Nil(t, err)
+	second, err := SignURL("https://example.com/3play", []byte("secret"), "1")
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+}
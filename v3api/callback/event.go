@@ -0,0 +1,116 @@
+package callback
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// EventType classifies callback events by the transcript status they report
+type EventType string
+
+// Event types
+const (
+	EventTranscriptComplete  EventType = "transcript.complete"
+	EventTranscriptCancelled EventType = "transcript.cancelled"
+	EventTranscriptFailed    EventType = "transcript.failed"
+	EventTranscriptUpdated   EventType = "transcript.updated"
+)
+
+// Event is a callback delivered by 3Play
+type Event struct {
+	Type                EventType
+	MediaFileID         string
+	TranscriptID        string
+	Status              string
+	ReferenceID         string
+	CancellationReason  string
+	CancellationDetails string
+	// Fields holds every field of the payload, as strings
+	Fields     map[string]string
+	ReceivedAt time.Time
+}
+
+// payload field names, in order of preference
+var eventFields = map[string][]string{
+	"media_file_id":        {"media_file_id", "file_id"},
+	"transcript_id":        {"transcript_id", "id"},
+	"status":               {"status", "state"},
+	"reference_id":         {"reference_id"},
+	"cancellation_reason":  {"cancellation_reason"},
+	"cancellation_details": {"cancellation_details"},
+}
+
+// parseEvent reads a callback payload, sent either as JSON or as a form
+func parseEvent(r *http.Request, body io.Reader) (*Event, error) {
+	fields := map[string]string{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		raw := map[string]interface{}{}
+		decoder := json.NewDecoder(body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid callback payload: %v", err)
+		}
+		for key, value := range raw {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				fields[key] = v
+			case json.Number:
+				fields[key] = v.String()
+			default:
+				encoded, _ := json.Marshal(v)
+				fields[key] = string(encoded)
+			}
+		}
+	} else {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid callback payload: %v", err)
+		}
+		for key := range form {
+			fields[key] = form.Get(key)
+		}
+	}
+
+	get := func(name string) string {
+		for _, key := range eventFields[name] {
+			if v := fields[key]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	event := &Event{
+		MediaFileID:         get("media_file_id"),
+		TranscriptID:        get("transcript_id"),
+		Status:              get("status"),
+		ReferenceID:         get("reference_id"),
+		CancellationReason:  get("cancellation_reason"),
+		CancellationDetails: get("cancellation_details"),
+		Fields:              fields,
+	}
+	switch event.Status {
+	case v3api.TranscriptStatusComplete:
+		event.Type = EventTranscriptComplete
+	case v3api.TranscriptStatusCancelled:
+		event.Type = EventTranscriptCancelled
+	case v3api.TranscriptStatusFailed:
+		event.Type = EventTranscriptFailed
+	default:
+		event.Type = EventTranscriptUpdated
+	}
+	return event, nil
+}
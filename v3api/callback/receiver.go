@@ -0,0 +1,196 @@
+package callback
+
+import (
+	"bytes"
+	"container/heap"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultReplayWindow is how long deliveries are remembered to reject replays
+const DefaultReplayWindow = 7 * 24 * time.Hour
+
+// maxPayloadSize bounds the callback body read by the receiver
+const maxPayloadSize = 1 << 20
+
+// ReplayCache remembers the deliveries a Receiver accepted
+type ReplayCache interface {
+	// Add records key until expiry, returning false if it's already recorded
+	Add(key string, expiry time.Time) bool
+	// Remove forgets key, so that a delivery that failed can be retried
+	Remove(key string)
+}
+
+// Receiver is an http.Handler that verifies 3Play callbacks and dispatches
+// them to a Handler
+type Receiver struct {
+	secret       []byte
+	handler      Handler
+	maxAge       time.Duration
+	replayWindow time.Duration
+	cache        ReplayCache
+	now          func() time.Time
+}
+
+// Option configures a Receiver
+type Option func(*Receiver)
+
+// WithMaxAge rejects callback URLs signed longer than d ago. By default signed
+// URLs don't expire, since transcripts can take days to complete.
+func WithMaxAge(d time.Duration) Option {
+	return func(r *Receiver) {
+		r.maxAge = d
+	}
+}
+
+// WithReplayCache sets where accepted deliveries are recorded, for example
+// a cache shared by several instances. It defaults to an in-memory cache.
+func WithReplayCache(cache ReplayCache) Option {
+	return func(r *Receiver) {
+		r.cache = cache
+	}
+}
+
+// WithReplayWindow sets how long accepted deliveries are remembered. It
+// defaults to DefaultReplayWindow.
+func WithReplayWindow(d time.Duration) Option {
+	return func(r *Receiver) {
+		r.replayWindow = d
+	}
+}
+
+// NewReceiver returns a Receiver verifying callback URLs signed with secret
+func NewReceiver(secret []byte, handler Handler, opts ...Option) *Receiver {
+	r := &Receiver{
+		secret:       secret,
+		handler:      handler,
+		replayWindow: DefaultReplayWindow,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.cache == nil {
+		r.cache = NewMemoryReplayCache(r.now)
+	}
+	return r
+}
+
+// ServeHTTP verifies the callback, rejects replays and dispatches the event.
+// A delivery is identified by the nonce of its signed URL and the status it
+// reports, so one URL can report each status change of a transcript once.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := req.URL.Query()
+	now := r.now()
+	if err := verify(r.secret, query, r.maxAge, now); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "invalid callback payload", http.StatusBadRequest)
+		return
+	}
+	event, err := parseEvent(req, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	signedID := query.Get(ParamMediaFileID)
+	if event.MediaFileID == "" {
+		event.MediaFileID = signedID
+	}
+	if event.MediaFileID != signedID {
+		http.Error(w, "callback: media file doesn't match the signed URL", http.StatusUnauthorized)
+		return
+	}
+	event.ReceivedAt = now
+
+	key := query.Get(ParamNonce) + ":" + event.Status
+	if !r.cache.Add(key, now.Add(r.replayWindow)) {
+		http.Error(w, ErrReplayed.Error(), http.StatusConflict)
+		return
+	}
+	if err := r.handler.HandleEvent(req.Context(), event); err != nil {
+		r.cache.Remove(key)
+		http.Error(w, "callback handler failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MemoryReplayCache is an in-memory ReplayCache. Expired entries are pruned
+// as new ones are added, oldest expiry first.
+type MemoryReplayCache struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	entries  map[string]time.Time
+	expiries expiryHeap
+}
+
+// NewMemoryReplayCache returns an empty MemoryReplayCache. A nil now uses
+// time.Now.
+func NewMemoryReplayCache(now func() time.Time) *MemoryReplayCache {
+	if now == nil {
+		now = time.Now
+	}
+	return &MemoryReplayCache{now: now, entries: map[string]time.Time{}}
+}
+
+// Add records key until expiry, returning false if it's already recorded
+func (c *MemoryReplayCache) Add(key string, expiry time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prune(c.now())
+	if _, ok := c.entries[key]; ok {
+		return false
+	}
+	c.entries[key] = expiry
+	heap.Push(&c.expiries, expiryEntry{key, expiry})
+	return true
+}
+
+// Remove forgets key
+func (c *MemoryReplayCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// prune deletes the entries expired at now. Heap entries of keys removed or
+// added again since are skipped. It must be called with c.mu held.
+func (c *MemoryReplayCache) prune(now time.Time) {
+	for len(c.expiries) > 0 && now.After(c.expiries[0].expiry) {
+		e := heap.Pop(&c.expiries).(expiryEntry)
+		if expiry, ok := c.entries[e.key]; ok && expiry.Equal(e.expiry) {
+			delete(c.entries, e.key)
+		}
+	}
+}
+
+type expiryEntry struct {
+	key    string
+	expiry time.Time
+}
+
+// expiryHeap orders entries by expiry, implementing heap.Interface
+type expiryHeap []expiryEntry
+
+func (h expiryHeap) Len() int            { return len(h) }
+func (h expiryHeap) Less(i, j int) bool  { return h[i].expiry.Before(h[j].expiry) }
+func (h expiryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x interface{}) { *h = append(*h, x.(expiryEntry)) }
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,144 @@
+package callback_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/nytimes/threeplay/v3api/callback"
+	"github.com/stretchr/testify/assert"
+)
+
+var secret = []byte("callback-secret")
+
+type recorder struct {
+	mu     sync.Mutex
+	events []*callback.Event
+	err    error
+}
+
+func (r *recorder) HandleEvent(ctx context.Context, event *callback.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func post(t *testing.T, handler http.Handler, target, contentType, body string) int {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestReceiverWithFakeServer(t *testing.T) {
+	assert := assert.New(t)
+	events := &recorder{}
+	receiver := httptest.NewServer(callback.NewReceiver(secret, events))
+	defer receiver.Close()
+
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	fileID := server.AddFile("speech.mp4")
+	mediaFileID := strconv.Itoa(fileID)
+	callbackURL, err := callback.SignURL(receiver.URL+"/3play", secret, mediaFileID)
+	assert.Nil(err)
+	_, err = client.OrderTranscript(mediaFileID, callbackURL, "asr", v3api.CallParams{})
+	assert.Nil(err)
+
+	server.Advance(threeplaytest.DefaultTurnaround)
+	assert.Len(events.events, 1)
+	event := events.events[0]
+	assert.Equal(callback.EventTranscriptComplete, event.Type)
+	assert.Equal(mediaFileID, event.MediaFileID)
+	assert.Equal(v3api.TranscriptStatusComplete, event.Status)
+	assert.NotEmpty(event.TranscriptID)
+}
+
+func TestReceiverFormPayload(t *testing.T) {
+	assert := assert.New(t)
+	events := &recorder{}
+	receiver := callback.NewReceiver(secret, events)
+	callbackURL, _ := callback.SignURL("https://example.com/3play", secret, "42")
+
+	code := post(t, receiver, callbackURL, "application/x-www-form-urlencoded",
+		"file_id=42&status=cancelled&cancellation_reason=media_error&cancellation_details=No+audio")
+	assert.Equal(http.StatusNoContent, code)
+	assert.Len(events.events, 1)
+	event := events.events[0]
+	assert.Equal(callback.EventTranscriptCancelled, event.Type)
+	assert.Equal("42", event.MediaFileID)
+	assert.Equal("media_error", event.CancellationReason)
+	assert.Equal("No audio", event.CancellationDetails)
+}
+
+func TestReceiverRejections(t *testing.T) {
+	assert := assert.New(t)
+	events := &recorder{}
+	receiver := callback.NewReceiver(secret, events)
+	callbackURL, _ := callback.SignURL("https://example.com/3play", secret, "42")
+	otherSecretURL, _ := callback.SignURL("https://example.com/3play", []byte("other"), "42")
+	payload := `{"media_file_id":42,"status":"complete"}`
+
+	assert.Equal(http.StatusUnauthorized, post(t, receiver, otherSecretURL, "application/json", payload))
+	assert.Equal(http.StatusUnauthorized, post(t, receiver, "https://example.com/3play?media_file_id=42", "application/json", payload))
+	assert.Equal(http.StatusUnauthorized, post(t, receiver, callbackURL, "application/json", `{"media_file_id":43,"status":"complete"}`))
+	assert.Equal(http.StatusBadRequest, post(t, receiver, callbackURL, "application/json", `{`))
+
+	assert.Equal(http.StatusNoContent, post(t, receiver, callbackURL, "application/json", `{"media_file_id":42,"status":"in_progress"}`))
+	assert.Equal(http.StatusNoContent, post(t, receiver, callbackURL, "application/json", payload))
+	assert.Equal(http.StatusConflict, post(t, receiver, callbackURL, "application/json", payload))
+	// the payload isn't signed: altering it doesn't make a new delivery
+	assert.Equal(http.StatusConflict, post(t, receiver, callbackURL, "application/json", payload+" "))
+	assert.Len(events.events, 2)
+	assert.Equal(callback.EventTranscriptUpdated, events.events[0].Type)
+	assert.Equal(callback.EventTranscriptComplete, events.events[1].Type)
+}
+
+func TestMemoryReplayCache(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Unix(1000, 0)
+	cache := callback.NewMemoryReplayCache(func() time.Time { return now })
+
+	assert.True(cache.Add("a", now.Add(time.Minute)))
+	assert.True(cache.Add("b", now.Add(time.Hour)))
+	assert.False(cache.Add("a", now.Add(time.Hour)))
+
+	now = now.Add(2 * time.Minute)
+	assert.True(cache.Add("a", now.Add(time.Minute)))
+	assert.False(cache.Add("b", now.Add(time.Hour)))
+
+	// an entry added again after being removed keeps its new expiry
+	cache.Remove("b")
+	assert.True(cache.Add("b", now.Add(2*time.Hour)))
+	now = now.Add(90 * time.Minute)
+	assert.False(cache.Add("b", now))
+	assert.True(cache.Add("a", now.Add(time.Minute)))
+}
+
+func TestReceiverHandlerErrorAllowsRedelivery(t *testing.T) {
+	assert := assert.New(t)
+	events := &recorder{err: errors.New("database down")}
+	receiver := callback.NewReceiver(secret, events)
+	callbackURL, _ := callback.SignURL("https://example.com/3play", secret, "42")
+	payload := `{"media_file_id":"42","status":"complete"}`
+
+	assert.Equal(http.StatusInternalServerError, post(t, receiver, callbackURL, "application/json", payload))
+	events.err = nil
+	assert.Equal(http.StatusNoContent, post(t, receiver, callbackURL, "application/json", payload))
+	assert.Len(events.events, 1)
+}
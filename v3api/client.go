@@ -4,19 +4,52 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/nytimes/threeplay/internal/transport"
 	"github.com/nytimes/threeplay/types"
-	"github.com/sethgrid/pester"
 )
 
 // Client 3Play Media API client
 type Client struct {
-	apiKey     string
-	httpClient *pester.Client
+	apiKey      string
+	httpClient  *transport.Client
+	baseURL     *url.URL
+	optionErr   error
+	formats     formatCache
+	maxBody     int64
+	maxDownload int64
+}
+
+// Option configures optional behavior of a Client
+type Option func(*Client)
+
+// WithBaseURL sets the base URL used for API calls, for example to point the
+// client at a staging environment or a local stand-in. The /v3 prefix is added
+// by the client, so the same base URL can be shared with v2api. It defaults to
+// types.ThreePlayBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		u, err := transport.ParseBaseURL(baseURL)
+		if err != nil {
+			if c.optionErr == nil {
+				c.optionErr = err
+			}
+			return
+		}
+		c.baseURL = u
+	}
+}
+
+// WithMaxBodySize sets the size above which API responses fail with
+// ErrBodyTooLarge. It defaults to 10 MiB. Transcript downloads are capped by
+// WithMaxDownloadSize instead. A size of 0 or less removes the cap.
+func WithMaxBodySize(size int64) Option {
+	return func(c *Client) {
+		c.maxBody = size
+	}
 }
 
 // Error representation of 3Play API error
@@ -33,55 +66,67 @@ var (
 )
 
 // NewClient returns a 3Play Media client
-func NewClient(apiKey string) *Client {
-	return NewClientWithHTTPClient(apiKey, &http.Client{Timeout: 10 * time.Second})
+func NewClient(apiKey string, opts ...Option) *Client {
+	return NewClientWithHTTPClient(apiKey, &http.Client{Timeout: 10 * time.Second}, opts...)
 }
 
 // NewClientWithHTTPClient returns a 3Play Media client with a custom http client
-func NewClientWithHTTPClient(apiKey string, client *http.Client) *Client {
-	httpClient := pester.NewExtendedClient(client)
-	httpClient.MaxRetries = 5
-	httpClient.Backoff = pester.ExponentialJitterBackoff
-	return &Client{
-		apiKey:     apiKey,
-		httpClient: httpClient,
+func NewClientWithHTTPClient(apiKey string, client *http.Client, opts ...Option) *Client {
+	baseURL, _ := url.Parse(types.ThreePlayBaseURL)
+	c := &Client{
+		apiKey:      apiKey,
+		httpClient:  transport.New(client),
+		baseURL:     baseURL,
+		maxBody:     transport.DefaultMaxBodySize,
+		maxDownload: DefaultMaxDownloadSize,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func (c *Client) createURL(endpoint string) url.URL {
-	return url.URL{
-		Scheme: "https",
-		Host:   types.ThreePlayHost,
-		Path:   fmt.Sprintf("/v3%v", endpoint),
+// createURL is the single place where request URLs are built. Invalid base
+// URLs given through options are reported here, since constructors can't fail.
+func (c *Client) createURL(endpoint string, query url.Values) (string, error) {
+	if c.optionErr != nil {
+		return "", c.optionErr
 	}
+	return transport.BuildURL(c.baseURL, fmt.Sprintf("/v3%v", endpoint), query), nil
 }
 
-func parseResponse(res *http.Response, ref interface{}) error {
-	responseData, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return err
-	}
-	err = checkForAPIError(responseData)
+// parseResponse reads and closes the body of res and decodes it into ref
+func (c *Client) parseResponse(res *http.Response, ref interface{}) error {
+	responseData, err := transport.ReadBody(res, c.maxBody)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(responseData, ref)
+	return parseResponseData(res, responseData, ref)
+}
 
-	return err
+// parseResponseData decodes the body of res into ref. Responses with an
+// error status must carry the code of a 3Play error payload, so that pages
+// served by proxies are reported with their HTTP status.
+func parseResponseData(res *http.Response, responseData []byte, ref interface{}) error {
+	if err := checkForAPIError(responseData); err != nil {
+		return withResponse(err, res)
+	}
+	if !transport.SuccessStatus(res.StatusCode) {
+		var payload struct {
+			Code int `json:"code"`
+		}
+		if err := json.Unmarshal(responseData, &payload); err != nil || payload.Code == 0 {
+			return newStatusError(res, responseData)
+		}
+	}
+	return json.Unmarshal(responseData, ref)
 }
 
 func checkForAPIError(responseData []byte) error {
 	apiError := Error{}
 	json.Unmarshal(responseData, &apiError)
 	if apiError.IsError {
-		if _, ok := apiError.Errors["authentication"]; ok {
-			return ErrUnauthorized
-		}
-		if _, ok := apiError.Errors["not_found"]; ok {
-			return ErrNotFound
-		}
-
-		return errors.New("api error: " + string(responseData))
+		return newLegacyAPIError(apiError, responseData)
 	}
 	return nil
 }
@@ -1,7 +1,12 @@
 package v3api
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,3 +53,102 @@ func TestCheckForAPIError(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateURL(t *testing.T) {
+	assert := assert.New(t)
+
+	client := NewClient("api-key")
+	endpoint, err := client.createURL("/files", nil)
+	assert.Nil(err)
+	assert.Equal("https://api.3playmedia.com/v3/files", endpoint)
+
+	client = NewClient("api-key", WithBaseURL("http://127.0.0.1:8080/"))
+	endpoint, err = client.createURL("/transcripts/1", url.Values{"api_key": {"api-key"}})
+	assert.Nil(err)
+	assert.Equal("http://127.0.0.1:8080/v3/transcripts/1?api_key=api-key", endpoint)
+}
+
+func TestInvalidBaseURLOption(t *testing.T) {
+	assert := assert.New(t)
+
+	client := NewClient("api-key", WithBaseURL("not-a-url"))
+	link, err := client.GetEditingLink("1", 2, CallParams{})
+	assert.Empty(link)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid base URL")
+}
+
+type trackedBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// bodyTracker replies to every request with status and body and remembers
+// the bodies it handed out
+type bodyTracker struct {
+	status int
+	body   string
+
+	mu     sync.Mutex
+	bodies []*trackedBody
+}
+
+func (b *bodyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	body := &trackedBody{Reader: strings.NewReader(b.body)}
+	b.bodies = append(b.bodies, body)
+	return &http.Response{StatusCode: b.status, Body: body, Header: http.Header{}, Request: req}, nil
+}
+
+func TestResponseBodiesAreClosed(t *testing.T) {
+	for _, tracker := range []*bodyTracker{
+		{status: http.StatusOK, body: `{"code":200,"data":{"id":1}}`},
+		{status: http.StatusNotFound, body: `{"code":404,"error":{"type":"not_found_error","message":"Not found"}}`},
+		{status: http.StatusBadGateway, body: "<html>Bad gateway</html>"},
+	} {
+		client := NewClientWithHTTPClient("api-key", &http.Client{Transport: tracker},
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+		ctx := context.Background()
+		client.GetFile("1", CallParams{})
+		client.ListFiles(ListFilesOptions{}, CallParams{})
+		client.UpdateFile("1", url.Values{"name": {"new"}}, CallParams{})
+		client.ArchiveFile("1", CallParams{})
+		client.UploadFileFromURL(url.Values{"source_url": {"https://example.com/video.mp4"}}, CallParams{})
+		client.UploadFile(ctx, strings.NewReader("media"), "video.mp4", UploadOptions{})
+		client.OrderTranscript("1", "", "asr", CallParams{})
+		client.GetTranscriptInfo("1", CallParams{})
+		client.GetTranscriptText("1", "", "vtt", CallParams{})
+		client.CancelTranscript("1", CallParams{})
+		client.GetEditingLink("1", 1, CallParams{})
+		client.RefreshOutputFormats(ctx, CallParams{})
+
+		assert.True(t, len(tracker.bodies) >= 12)
+		for _, body := range tracker.bodies {
+			assert.True(t, body.closed, "%d %s", tracker.status, tracker.body)
+		}
+	}
+}
+
+func TestWithMaxBodySize(t *testing.T) {
+	assert := assert.New(t)
+	tracker := &bodyTracker{status: http.StatusOK, body: `{"code":200,"data":{"id":1}}`}
+
+	client := NewClientWithHTTPClient("api-key", &http.Client{Transport: tracker}, WithMaxBodySize(5))
+	file, err := client.GetFile("1", CallParams{})
+	assert.Nil(file)
+	assert.True(errors.Is(err, ErrBodyTooLarge))
+
+	client = NewClientWithHTTPClient("api-key", &http.Client{Transport: tracker}, WithMaxBodySize(0))
+	file, err = client.GetFile("1", CallParams{})
+	assert.Nil(err)
+	assert.Equal(1, file.ID)
+}
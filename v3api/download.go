@@ -0,0 +1,228 @@
+package v3api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nytimes/threeplay/internal/transport"
+)
+
+// DefaultMaxDownloadSize is the size above which transcript downloads fail
+// with ErrBodyTooLarge, unless changed with WithMaxDownloadSize
+const DefaultMaxDownloadSize = 1 << 30
+
+// ErrBodyTooLarge is returned when a response or download exceeds the
+// maximum size set on the client
+var ErrBodyTooLarge = transport.ErrBodyTooLarge
+
+// errorPrefixSize bounds the bytes of a download checked for an API error.
+// Errors are small: bodies that don't end within it are never errors.
+const errorPrefixSize = 64 << 10
+
+var errInvalidText = errors.New("invalid transcript text response")
+
+// WithMaxDownloadSize sets the size above which transcript downloads fail
+// with ErrBodyTooLarge. The size applies to the decoded transcript. A size of
+// 0 or less removes the cap.
+func WithMaxDownloadSize(size int64) Option {
+	return func(c *Client) {
+		c.maxDownload = size
+	}
+}
+
+// downloadText streams the "data" string of the ThreePlayTranscriptTextResponse
+// returned by endpoint to w
+func (c *Client) downloadText(ctx context.Context, w io.Writer, endpoint string) (int64, error) {
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return 0, err
+	}
+	defer transport.CloseBody(res)
+
+	body := bufio.NewReaderSize(res.Body, errorPrefixSize)
+	prefix, err := body.Peek(errorPrefixSize)
+	switch err {
+	case nil, bufio.ErrBufferFull:
+	case io.EOF:
+		response := &ThreePlayTranscriptTextResponse{}
+		if err := parseResponseData(res, prefix, response); err != nil {
+			return 0, err
+		}
+		if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+			return 0, err
+		}
+		n, err := io.WriteString(transport.LimitWriter(w, c.maxDownload), response.Data)
+		return int64(n), err
+	default:
+		return 0, err
+	}
+	if !transport.SuccessStatus(res.StatusCode) {
+		return 0, newStatusError(res, prefix)
+	}
+
+	cw := &countingWriter{w: transport.LimitWriter(w, c.maxDownload)}
+	if err := streamTextData(res, cw, body); err != nil {
+		return cw.n, err
+	}
+	return cw.n, nil
+}
+
+// streamTextData finds the "data" key of the JSON object read from r and
+// writes its decoded string value to w
+func streamTextData(res *http.Response, w io.Writer, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return errInvalidText
+	}
+	code := http.StatusOK
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case "data":
+			if err := checkResponseCode(res, code, ThreePlayError{}); err != nil {
+				return err
+			}
+			return copyJSONString(w, bufio.NewReader(io.MultiReader(dec.Buffered(), r)))
+		case "code":
+			if err := dec.Decode(&code); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	return errInvalidText
+}
+
+// copyJSONString reads the ": value" following an object key from r and
+// writes the value, which must be a string, to w unescaped
+func copyJSONString(w io.Writer, r *bufio.Reader) error {
+	for _, want := range []byte{':', '"'} {
+		b, err := skipSpace(r)
+		if err != nil {
+			return err
+		}
+		if b != want {
+			return errInvalidText
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		switch b {
+		case '"':
+			return out.Flush()
+		case '\\':
+			if err := unescape(out, r); err != nil {
+				return err
+			}
+		default:
+			if err := out.WriteByte(b); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var escapes = map[byte]byte{
+	'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t',
+}
+
+// unescape writes the character escaped by the sequence following a
+// backslash. Invalid surrogates are replaced with utf8.RuneError, as
+// encoding/json does.
+func unescape(out *bufio.Writer, r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if b != 'u' {
+		c, ok := escapes[b]
+		if !ok {
+			return fmt.Errorf("%w: invalid escape \\%c", errInvalidText, b)
+		}
+		return out.WriteByte(c)
+	}
+
+	r1, err := readHex(r)
+	if err != nil {
+		return err
+	}
+	if utf16.IsSurrogate(r1) {
+		if next, err := r.Peek(6); err == nil && next[0] == '\\' && next[1] == 'u' {
+			if r2, err := strconv.ParseUint(string(next[2:]), 16, 16); err == nil {
+				if dec := utf16.DecodeRune(r1, rune(r2)); dec != utf8.RuneError {
+					r.Discard(6)
+					r1 = dec
+				}
+			}
+		}
+		if utf16.IsSurrogate(r1) {
+			r1 = utf8.RuneError
+		}
+	}
+	_, err = out.WriteRune(r1)
+	return err
+}
+
+func readHex(r *bufio.Reader) (rune, error) {
+	var hex [4]byte
+	if _, err := io.ReadFull(r, hex[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	v, err := strconv.ParseUint(string(hex[:]), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid escape \\u%s", errInvalidText, hex[:])
+	}
+	return rune(v), nil
+}
+
+func skipSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return b, nil
+		}
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
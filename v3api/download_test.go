@@ -0,0 +1,119 @@
+package v3api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+// largeText is larger than the prefix checked for errors, so it is streamed,
+// and has characters escaped in JSON strings
+var largeText = strings.Repeat("WEBVTT \"café\" \\ \t<b>\U0001F600</b>\n", 5000)
+
+func mockTranscriptText(body string) {
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/3633088/text").
+		MatchParam("api_key", "api-key").
+		MatchParam("output_format_id", "139").
+		Reply(200).
+		BodyString(body)
+}
+
+func TestGetTranscriptTextTo(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	data, _ := json.Marshal(largeText)
+	mockTranscriptText(`{"code": 200, "meta": {"page": 1}, "data": ` + string(data) + `}`)
+
+	client := v3api.NewClient("api-key")
+	var buf bytes.Buffer
+	n, err := client.GetTranscriptTextTo(context.Background(), &buf, "3633088", "", types.WebVTT, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal(int64(len(largeText)), n)
+	assert.Equal(largeText, buf.String())
+}
+
+func TestGetTranscriptTextToEscapes(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	padding := strings.Repeat(" ", 70000)
+	mockTranscriptText(`{"data":` + padding + `"Aé😀\ud83d\n\/\b\f\r"}`)
+
+	client := v3api.NewClient("api-key")
+	var buf bytes.Buffer
+	_, err := client.GetTranscriptTextTo(context.Background(), &buf, "3633088", "", types.WebVTT, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal("Aé\U0001F600�\n/\b\f\r", buf.String())
+}
+
+func TestGetTranscriptTextToTruncated(t *testing.T) {
+	defer gock.Off()
+
+	data, _ := json.Marshal(largeText)
+	mockTranscriptText(`{"code": 200, "data": ` + string(data[:len(data)-10]))
+
+	client := v3api.NewClient("api-key")
+	_, err := client.GetTranscriptTextTo(context.Background(), &bytes.Buffer{}, "3633088", "", types.WebVTT, v3api.CallParams{})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestGetTranscriptTextToErrorCode(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	data, _ := json.Marshal(largeText)
+	mockTranscriptText(`{"code": 500, "data": ` + string(data) + `}`)
+
+	client := v3api.NewClient("api-key")
+	var buf bytes.Buffer
+	_, err := client.GetTranscriptTextTo(context.Background(), &buf, "3633088", "", types.WebVTT, v3api.CallParams{})
+	var apiErr *v3api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(500, apiErr.Code)
+	assert.Empty(buf.String())
+}
+
+func TestGetTranscriptTextToError(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/9846/text").
+		Reply(500).
+		File("../fixtures/v3_unknown_error.json")
+
+	client := v3api.NewClient("api-key", v3api.WithRetryPolicy(v3api.RetryPolicy{MaxAttempts: 1}))
+	var buf bytes.Buffer
+	n, err := client.GetTranscriptTextTo(context.Background(), &buf, "9846", "", types.WebVTT, v3api.CallParams{})
+	assert.Equal(int64(0), n)
+	assert.Equal("500: standard_error-Internal server error", err.Error())
+	assert.Empty(buf.String())
+}
+
+func TestGetTranscriptTextToMaxDownloadSize(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	for _, text := range []string{"small transcript", largeText} {
+		data, _ := json.Marshal(text)
+		mockTranscriptText(`{"code": 200, "data": ` + string(data) + `}`)
+
+		client := v3api.NewClient("api-key", v3api.WithMaxDownloadSize(10))
+		var buf bytes.Buffer
+		n, err := client.GetTranscriptTextTo(context.Background(), &buf, "3633088", "", types.WebVTT, v3api.CallParams{})
+		assert.True(errors.Is(err, v3api.ErrBodyTooLarge))
+		assert.Equal(int64(10), n)
+		assert.Equal(text[:10], buf.String())
+	}
+}
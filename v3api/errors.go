@@ -0,0 +1,166 @@
+package v3api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/nytimes/threeplay/internal/transport"
+)
+
+// Error types reported by the 3Play v3 API
+const (
+	ErrorTypeParameter      = "parameter_error"
+	ErrorTypeValidation     = "validation_error"
+	ErrorTypeAuthentication = "authentication_error"
+	ErrorTypeForbidden      = "forbidden_error"
+	ErrorTypeNotFound       = "not_found_error"
+	ErrorTypeStandard       = "standard_error"
+	// ErrorTypeHTTPStatus is reported for responses with an error status
+	// whose body isn't a 3Play error, e.g. a page served by a proxy. The
+	// Message quotes the start of the body.
+	ErrorTypeHTTPStatus = "http_status"
+)
+
+// APIError is returned when 3Play reports an error. It matches
+// ErrUnauthorized and ErrNotFound with errors.Is, and can be unwrapped with
+// errors.As by callers that need to branch on the error type, e.g. telling a
+// validation_error apart from a forbidden_error on cancellation.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the status code reported in the response payload
+	Code int
+	// Type is the 3Play error type, see the ErrorType constants
+	Type string
+	// Message is the human readable description of the error
+	Message string
+	// Errors holds per-field error messages, when provided
+	Errors FieldErrors
+	// Endpoint is the path of the endpoint that returned the error
+	Endpoint string
+
+	text string
+}
+
+func (e *APIError) Error() string {
+	if e.text != "" {
+		return e.text
+	}
+	return fmt.Sprintf("%v: %v-%v", e.Code, e.Type, e.Message)
+}
+
+// Is reports whether the error matches one of the package sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized || e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound || e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// FieldErrors maps request fields to their error messages
+type FieldErrors map[string][]string
+
+// UnmarshalJSON accepts both a single message and a list of messages per
+// field. Payloads of any other shape are ignored so that they don't hide the
+// error they're attached to.
+func (f *FieldErrors) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	errs := FieldErrors{}
+	for field, value := range raw {
+		var messages []string
+		if err := json.Unmarshal(value, &messages); err == nil {
+			errs[field] = messages
+			continue
+		}
+		var message string
+		if err := json.Unmarshal(value, &message); err == nil {
+			errs[field] = []string{message}
+		}
+	}
+	*f = errs
+	return nil
+}
+
+// checkResponseCode returns an *APIError when the code of a decoded response
+// isn't 200
+func checkResponseCode(res *http.Response, code int, apiError ThreePlayError) error {
+	if code == http.StatusOK {
+		return nil
+	}
+	return withResponse(&APIError{
+		Code:    code,
+		Type:    apiError.Type,
+		Message: apiError.Message,
+		Errors:  apiError.Errors,
+	}, res)
+}
+
+func newStatusError(res *http.Response, body []byte) error {
+	e := &APIError{
+		Code:    res.StatusCode,
+		Type:    ErrorTypeHTTPStatus,
+		Message: transport.BodySnippet(body),
+	}
+	e.text = fmt.Sprintf("unexpected HTTP status %d %s", res.StatusCode, http.StatusText(res.StatusCode))
+	if e.Message != "" {
+		e.text += ": " + e.Message
+	}
+	return withResponse(e, res)
+}
+
+func newLegacyAPIError(apiError Error, responseData []byte) *APIError {
+	e := &APIError{
+		Errors: FieldErrors{},
+		text:   "api error: " + string(responseData),
+	}
+	keys := make([]string, 0, len(apiError.Errors))
+	for key, message := range apiError.Errors {
+		e.Errors[key] = []string{message}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	switch {
+	case apiError.Errors["authentication"] != "":
+		e.Code, e.Type = http.StatusUnauthorized, ErrorTypeAuthentication
+		e.Message = apiError.Errors["authentication"]
+		e.text = ErrUnauthorized.Error()
+	case apiError.Errors["not_found"] != "":
+		e.Code, e.Type = http.StatusNotFound, ErrorTypeNotFound
+		e.Message = apiError.Errors["not_found"]
+		e.text = ErrNotFound.Error()
+	case len(keys) > 0:
+		e.Type = keys[0]
+		e.Message = apiError.Errors[e.Type]
+	}
+	return e
+}
+
+// withResponse records the HTTP status and endpoint of res on err, when it
+// is an *APIError
+func withResponse(err error, res *http.Response) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.StatusCode = res.StatusCode
+		if res.Request != nil && res.Request.URL != nil {
+			apiErr.Endpoint = res.Request.URL.Path
+		}
+		transport.ReportAPIError(res, apiErr.Type)
+	}
+	return err
+}
+
+// Redact masks the API key and secret in s, a request URL or a message
+// quoting one, so it can be logged safely. Errors returned by the client are
+// already redacted.
+func Redact(s string) string {
+	return transport.Redact(s)
+}
@@ -0,0 +1,159 @@
+package v3api_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestAPIErrorForbiddenCancel(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/transcripts/843759/cancel").
+		Reply(403).
+		File("../fixtures/v3_cancel_403.json")
+
+	client := v3api.NewClient("api-key")
+	err := client.CancelTranscript("843759", v3api.CallParams{})
+
+	var apiErr *v3api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(403, apiErr.StatusCode)
+	assert.Equal(403, apiErr.Code)
+	assert.Equal(v3api.ErrorTypeForbidden, apiErr.Type)
+	assert.Equal("You cannot cancel this transcript at this time.", apiErr.Message)
+	assert.Equal("/v3/transcripts/843759/cancel", apiErr.Endpoint)
+	assert.False(errors.Is(err, v3api.ErrNotFound))
+}
+
+func TestAPIErrorNotFound(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/transcripts/order/asr").
+		Reply(404).
+		File("../fixtures/v3_transcript_order_404.json")
+
+	client := v3api.NewClient("api-key")
+	_, err := client.OrderTranscript("123456", "", "asr", v3api.CallParams{})
+	assert.True(errors.Is(err, v3api.ErrNotFound))
+	assert.False(errors.Is(err, v3api.ErrUnauthorized))
+}
+
+func TestAPIErrorLegacyTypes(t *testing.T) {
+	tests := []struct {
+		key, errorType string
+		sentinel       error
+	}{
+		{"authentication", v3api.ErrorTypeAuthentication, v3api.ErrUnauthorized},
+		{"not_found", v3api.ErrorTypeNotFound, v3api.ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			assert := assert.New(t)
+			defer gock.Off()
+
+			gock.New("https://api.3playmedia.com").
+				Get("/v3/transcripts/123").
+				Reply(200).
+				BodyString(`{"iserror":true,"errors":{"` + test.key + `":"legacy message"}}`)
+
+			client := v3api.NewClient("api-key")
+			_, err := client.GetTranscriptInfo("123", v3api.CallParams{})
+			assert.True(errors.Is(err, test.sentinel))
+			var apiErr *v3api.APIError
+			assert.True(errors.As(err, &apiErr))
+			assert.Equal(test.errorType, apiErr.Type)
+			assert.Equal("legacy message", apiErr.Message)
+		})
+	}
+}
+
+func TestAPIErrorFieldErrors(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/files").
+		Reply(422).
+		BodyString(`{"code":422,"error":{"type":"validation_error","message":"Validation failed","errors":{"name":["can't be blank"],"language_id":"is invalid"}}}`)
+
+	client := v3api.NewClient("api-key")
+	_, err := client.UploadFileFromURL(nil, v3api.CallParams{})
+	assert.Equal("422: validation_error-Validation failed", err.Error())
+
+	var apiErr *v3api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(v3api.ErrorTypeValidation, apiErr.Type)
+	assert.Equal(v3api.FieldErrors{
+		"name":        {"can't be blank"},
+		"language_id": {"is invalid"},
+	}, apiErr.Errors)
+}
+
+func TestAPIErrorHTTPStatus(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/files/123456").
+		Reply(503).
+		BodyString("<html>\n<body>Service unavailable</body>\n</html>")
+
+	client := v3api.NewClient("api-key", v3api.WithRetryPolicy(v3api.RetryPolicy{MaxAttempts: 1}))
+	file, err := client.GetFile("123456", v3api.CallParams{})
+	assert.Nil(file)
+	assert.Equal("unexpected HTTP status 503 Service Unavailable: <html> <body>Service unavailable</body> </html>", err.Error())
+
+	var apiErr *v3api.APIError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(503, apiErr.StatusCode)
+	assert.Equal(503, apiErr.Code)
+	assert.Equal(v3api.ErrorTypeHTTPStatus, apiErr.Type)
+	assert.Equal("<html> <body>Service unavailable</body> </html>", apiErr.Message)
+	assert.Equal("/v3/files/123456", apiErr.Endpoint)
+}
+
+func TestAPIErrorHTTPStatusJSON(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	// JSON that isn't a 3Play error, e.g. from an API gateway
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/files/123456").
+		Reply(403).
+		BodyString(`{"message":"Forbidden"}`)
+
+	client := v3api.NewClient("api-key")
+	_, err := client.GetFile("123456", v3api.CallParams{})
+	assert.Equal(`unexpected HTTP status 403 Forbidden: {"message":"Forbidden"}`, err.Error())
+}
+
+func TestErrorsAreRedacted(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(nil)
+	endpoint := server.URL
+	server.Close()
+
+	client := v3api.NewClient("api-key", v3api.WithBaseURL(endpoint),
+		v3api.WithRetryPolicy(v3api.RetryPolicy{MaxAttempts: 1}))
+	_, err := client.GetTranscriptInfo("123", v3api.CallParams{})
+	assert.NotNil(err)
+	assert.NotContains(err.Error(), "api-key")
+	var urlErr *url.Error
+	assert.True(errors.As(err, &urlErr))
+	assert.Contains(urlErr.URL, "api_key=REDACTED")
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "https://api.3playmedia.com/files?apikey=REDACTED&page=1",
+		v3api.Redact("https://api.3playmedia.com/files?apikey=api-key&page=1"))
+}
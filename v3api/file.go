@@ -1,16 +1,61 @@
 package v3api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
 	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nytimes/threeplay/internal/transport"
 )
 
+// ThreePlayFileResponse is the response of the single file endpoints
 type ThreePlayFileResponse struct {
 	Code  int                      `json:"code"`
 	Data  FileObjectRepresentation `json:"data"`
 	Error ThreePlayError           `json:"error"`
 }
 
+// ThreePlayFilesResponse is the response of the file listing
+type ThreePlayFilesResponse struct {
+	Code  int                        `json:"code"`
+	Data  []FileObjectRepresentation `json:"data"`
+	Meta  PageMeta                   `json:"meta"`
+	Error ThreePlayError             `json:"error"`
+}
+
+// PageMeta describes the page of a listing
+type PageMeta struct {
+	CurrentPage  int `json:"current_page"`
+	PerPage      int `json:"per_page"`
+	TotalEntries int `json:"total_entries"`
+	TotalPages   int `json:"total_pages"`
+}
+
+// FilesPage is a page of media files
+type FilesPage struct {
+	Files []FileObjectRepresentation
+	PageMeta
+}
+
+// ListFilesOptions filters and paginates ListFiles
+type ListFilesOptions struct {
+	// Page is the page to fetch, starting at 1
+	Page int
+	// PerPage is the number of files per page
+	PerPage int
+	// Filters are sent as query parameters, e.g. name, reference_id or
+	// batch_id
+	Filters url.Values
+}
+
+// FileObjectRepresentation represents a media file
 type FileObjectRepresentation struct {
 	ID          int     `json:"id"`
 	Name        string  `json:"name"`
@@ -24,27 +69,266 @@ type FileObjectRepresentation struct {
 // UploadFileFromURL uploads a file to threeplay using the file's URL and
 // returns the file ID.
 func (c *Client) UploadFileFromURL(options url.Values, callParams CallParams) (int, error) {
+	return c.UploadFileFromURLContext(context.Background(), options, callParams)
+}
+
+// UploadFileFromURLContext is like UploadFileFromURL but carries ctx through
+// the request and its retries.
+func (c *Client) UploadFileFromURLContext(ctx context.Context, options url.Values, callParams CallParams) (int, error) {
 	apiKey := c.setAPIKey(callParams.APIKey)
-	apiURL := c.createURL("/files")
+	apiURL, err := c.createURL("/files", nil)
+	if err != nil {
+		return 0, err
+	}
 	data := url.Values{}
 	data.Set("api_key", apiKey)
 	for key, val := range options {
 		data[key] = val
 	}
-	res, err := c.httpClient.PostForm(apiURL.String(), data)
+	res, err := c.httpClient.PostForm(ctx, apiURL, data)
 	if err != nil {
-		fmt.Println(err)
 		return 0, err
 	}
 
 	response := &ThreePlayFileResponse{}
-	if err := parseResponse(res, response); err != nil {
+	if err := c.parseResponse(res, response); err != nil {
 		return 0, err
 	}
 
-	if response.Code != 200 {
-		return 0, fmt.Errorf("%v: %v-%v", response.Code, response.Error.Type, response.Error.Message)
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return 0, err
 	}
 
 	return response.Data.ID, nil
 }
+
+// GetFile gets a single media file by ID
+func (c *Client) GetFile(mediaFileID string, callParams CallParams) (*FileObjectRepresentation, error) {
+	return c.GetFileContext(context.Background(), mediaFileID, callParams)
+}
+
+// GetFileContext is like GetFile but carries ctx through the request and its
+// retries.
+func (c *Client) GetFileContext(ctx context.Context, mediaFileID string, callParams CallParams) (*FileObjectRepresentation, error) {
+	endpoint, err := c.createURL(fmt.Sprintf("/files/%s", mediaFileID), url.Values{
+		"api_key": {c.setAPIKey(callParams.APIKey)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseFileResponse(res)
+}
+
+// ListFiles returns a page of media files
+func (c *Client) ListFiles(opts ListFilesOptions, callParams CallParams) (*FilesPage, error) {
+	return c.ListFilesContext(context.Background(), opts, callParams)
+}
+
+// ListFilesContext is like ListFiles but carries ctx through the request and
+// its retries.
+func (c *Client) ListFilesContext(ctx context.Context, opts ListFilesOptions, callParams CallParams) (*FilesPage, error) {
+	query := url.Values{}
+	for key, val := range opts.Filters {
+		query[key] = val
+	}
+	query.Set("api_key", c.setAPIKey(callParams.APIKey))
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	endpoint, err := c.createURL("/files", query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	response := &ThreePlayFilesResponse{}
+	if err := c.parseResponse(res, response); err != nil {
+		return nil, err
+	}
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return nil, err
+	}
+	return &FilesPage{Files: response.Data, PageMeta: response.Meta}, nil
+}
+
+// UpdateFile updates the metadata of a media file, such as name,
+// reference_id or batch_id, and returns the updated file
+func (c *Client) UpdateFile(mediaFileID string, data url.Values, callParams CallParams) (*FileObjectRepresentation, error) {
+	return c.UpdateFileContext(context.Background(), mediaFileID, data, callParams)
+}
+
+// UpdateFileContext is like UpdateFile but carries ctx through the request and
+// its retries.
+func (c *Client) UpdateFileContext(ctx context.Context, mediaFileID string, data url.Values, callParams CallParams) (*FileObjectRepresentation, error) {
+	if len(data) == 0 {
+		return nil, errors.New("must specify new data")
+	}
+	apiURL, err := c.createURL(fmt.Sprintf("/files/%s", mediaFileID), nil)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	for key, val := range data {
+		form[key] = val
+	}
+	form.Set("api_key", c.setAPIKey(callParams.APIKey))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseFileResponse(res)
+}
+
+// ArchiveFile archives a media file, removing it from listings
+func (c *Client) ArchiveFile(mediaFileID string, callParams CallParams) error {
+	return c.ArchiveFileContext(context.Background(), mediaFileID, callParams)
+}
+
+// ArchiveFileContext is like ArchiveFile but carries ctx through the request
+// and its retries.
+func (c *Client) ArchiveFileContext(ctx context.Context, mediaFileID string, callParams CallParams) error {
+	apiURL, err := c.createURL(fmt.Sprintf("/files/%s/archive", mediaFileID), nil)
+	if err != nil {
+		return err
+	}
+	data := url.Values{}
+	data.Set("api_key", c.setAPIKey(callParams.APIKey))
+	// archiving twice has no further effect
+	res, err := c.httpClient.PostForm(transport.MarkIdempotent(ctx), apiURL, data)
+	if err != nil {
+		return err
+	}
+	_, err = c.parseFileResponse(res)
+	return err
+}
+
+func (c *Client) parseFileResponse(res *http.Response) (*FileObjectRepresentation, error) {
+	response := &ThreePlayFileResponse{}
+	if err := c.parseResponse(res, response); err != nil {
+		return nil, err
+	}
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// UploadOptions configures UploadFile
+type UploadOptions struct {
+	// Params are sent as form fields along with the media, e.g. language_id,
+	// reference_id or batch_id
+	Params url.Values
+	// ContentType is the media type of the uploaded file. It defaults to
+	// application/octet-stream.
+	ContentType string
+	// Progress, when set, is called with the number of media bytes sent so
+	// far every time a chunk is written to the connection. It is called from
+	// the goroutine streaming the media, not the caller's.
+	Progress func(sent int64)
+	// CallParams contains the call params the caller wants to override
+	CallParams CallParams
+}
+
+// UploadFile uploads local media to threeplay as a multipart form. The media
+// is streamed from r as the request is sent, so it's never held in memory in
+// full. Since the body can't be replayed, the upload is never retried. r is
+// no longer read once UploadFile returns, whatever the outcome.
+func (c *Client) UploadFile(ctx context.Context, r io.Reader, name string, opts UploadOptions) (*FileObjectRepresentation, error) {
+	apiURL, err := c.createURL("/files", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	apiKey := c.setAPIKey(opts.CallParams.APIKey)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.CloseWithError(writeUploadForm(form, r, name, apiKey, opts))
+	}()
+	// unblock the writer when the request ended early, and wait for it to
+	// stop reading r
+	defer func() {
+		body.Close()
+		<-done
+	}()
+
+	req, err := http.NewRequestWithContext(transport.WithAPIKey(ctx, apiKey), http.MethodPost, apiURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseFileResponse(res)
+}
+
+func writeUploadForm(form *multipart.Writer, r io.Reader, name, apiKey string, opts UploadOptions) error {
+	fields := url.Values{}
+	for key, val := range opts.Params {
+		fields[key] = val
+	}
+	fields.Set("api_key", apiKey)
+	if fields.Get("name") == "" {
+		fields.Set("name", name)
+	}
+	for key, values := range fields {
+		for _, value := range values {
+			if err := form.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="source_file"; filename="%s"`, quoteEscaper.Replace(name)))
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if opts.Progress != nil {
+		part = &progressWriter{w: part, progress: opts.Progress}
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return err
+	}
+	return form.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// progressWriter reports the number of bytes written through it
+type progressWriter struct {
+	w        io.Writer
+	sent     int64
+	progress func(int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.sent += int64(n)
+	p.progress(p.sent)
+	return n, err
+}
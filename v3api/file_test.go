@@ -1,9 +1,21 @@
 package v3api_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/nytimes/threeplay/threeplaytest"
 	"github.com/nytimes/threeplay/v3api"
 	"github.com/stretchr/testify/assert"
 	gock "gopkg.in/h2non/gock.v1"
@@ -75,3 +87,183 @@ func TestUploadFileError(t *testing.T) {
 	assert.NotNil(err)
 	assert.Equal("400: parameter_error-Unrecognized parameters supplied: 'bad_param'", err.Error())
 }
+
+func TestUploadFileMultipart(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	media := bytes.Repeat([]byte("0123456789"), 100000)
+	var progress []int64
+	file, err := client.UploadFile(context.Background(), bytes.NewReader(media), "speech.mp4", v3api.UploadOptions{
+		Params:      url.Values{"language_id": {"1"}, "reference_id": {"ref-1"}},
+		ContentType: "video/mp4",
+		Progress:    func(sent int64) { progress = append(progress, sent) },
+	})
+	assert.Nil(err)
+	assert.NotZero(file.ID)
+	assert.Equal("speech.mp4", file.Name)
+	assert.Equal("ref-1", file.ReferenceID)
+	assert.Equal(int64(len(media)), server.UploadedSize(file.ID))
+	assert.NotEmpty(progress)
+	assert.Equal(int64(len(media)), progress[len(progress)-1])
+}
+
+func TestUploadFileStreamsParts(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v3/files", r.URL.Path)
+		assert.Equal(int64(-1), r.ContentLength)
+		reader, err := r.MultipartReader()
+		assert.Nil(err)
+		fields := map[string]string{}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(err)
+			data, _ := ioutil.ReadAll(part)
+			if part.FormName() == "source_file" {
+				assert.Equal(`my "clip".mp4`, part.FileName())
+				assert.Equal("application/octet-stream", part.Header.Get("Content-Type"))
+			}
+			fields[part.FormName()] = string(data)
+		}
+		assert.Equal("custom-key", fields["api_key"])
+		assert.Equal(`my "clip".mp4`, fields["name"])
+		assert.Equal("media", fields["source_file"])
+		http.ServeFile(w, r, "../fixtures/v3_file_upload_200.json")
+	}))
+	defer server.Close()
+
+	client := v3api.NewClient("api-key", v3api.WithBaseURL(server.URL))
+	file, err := client.UploadFile(context.Background(), strings.NewReader("media"), `my "clip".mp4`, v3api.UploadOptions{
+		CallParams: v3api.CallParams{APIKey: "custom-key"},
+	})
+	assert.Nil(err)
+	assert.Equal(3628518, file.ID)
+	assert.Equal(68841, file.BatchID)
+	assert.Equal([]int{1}, file.LanguageIDs)
+}
+
+func TestUploadFileReaderError(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	file, err := client.UploadFile(context.Background(), failingReader{}, "speech.mp4", v3api.UploadOptions{})
+	assert.Nil(file)
+	assert.NotNil(err)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("disk error")
+}
+
+// slowReader streams media without end, slowly, and tells whether it's
+// being read
+type slowReader struct {
+	reading int32
+	reads   int32
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	atomic.StoreInt32(&r.reading, 1)
+	defer atomic.StoreInt32(&r.reading, 0)
+	atomic.AddInt32(&r.reads, 1)
+	time.Sleep(5 * time.Millisecond)
+	if len(p) > 1024 {
+		p = p[:1024]
+	}
+	return len(p), nil
+}
+
+func TestUploadFileStopsReadingOnReturn(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+	}))
+	defer server.Close()
+	client := v3api.NewClient("api-key", v3api.WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	media := &slowReader{}
+	var progressed int32
+	file, err := client.UploadFile(ctx, media, "speech.mp4", v3api.UploadOptions{
+		Progress: func(int64) { atomic.StoreInt32(&progressed, 1) },
+	})
+	assert.Nil(file)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+
+	assert.Equal(int32(0), atomic.LoadInt32(&media.reading))
+	reads := atomic.LoadInt32(&media.reads)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(reads, atomic.LoadInt32(&media.reads))
+	assert.Equal(int32(1), atomic.LoadInt32(&progressed))
+}
+
+func TestFileCRUD(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	callParams := v3api.CallParams{}
+
+	var ids []string
+	for _, name := range []string{"first.mp4", "second.mp4", "third.mp4"} {
+		ids = append(ids, strconv.Itoa(server.AddFile(name)))
+	}
+
+	file, err := client.GetFile(ids[0], callParams)
+	assert.Nil(err)
+	assert.Equal("first.mp4", file.Name)
+
+	file, err = client.UpdateFile(ids[1], url.Values{"reference_id": {"ref-2"}, "name": {"renamed.mp4"}}, callParams)
+	assert.Nil(err)
+	assert.Equal("renamed.mp4", file.Name)
+	assert.Equal("ref-2", file.ReferenceID)
+
+	_, err = client.UpdateFile(ids[1], nil, callParams)
+	assert.Equal("must specify new data", err.Error())
+
+	page, err := client.ListFiles(v3api.ListFilesOptions{PerPage: 2, Page: 2}, callParams)
+	assert.Nil(err)
+	assert.Len(page.Files, 1)
+	assert.Equal(3, page.TotalEntries)
+	assert.Equal(2, page.TotalPages)
+	assert.Equal(2, page.CurrentPage)
+
+	page, err = client.ListFiles(v3api.ListFilesOptions{Filters: url.Values{"reference_id": {"ref-2"}}}, callParams)
+	assert.Nil(err)
+	assert.Len(page.Files, 1)
+	assert.Equal("renamed.mp4", page.Files[0].Name)
+
+	assert.Nil(client.ArchiveFile(ids[2], callParams))
+	_, err = client.GetFile(ids[2], callParams)
+	assert.True(errors.Is(err, v3api.ErrNotFound))
+	page, err = client.ListFiles(v3api.ListFilesOptions{}, callParams)
+	assert.Nil(err)
+	assert.Equal(2, page.TotalEntries)
+}
+
+func TestGetFileError(t *testing.T) {
+	assert := assert.New(t)
+
+	defer gock.Off()
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/files/123").
+		MatchParam("api_key", "api-key").
+		Reply(404).
+		File("../fixtures/v3_transcript_order_404.json")
+
+	client := v3api.NewClient("api-key")
+	file, err := client.GetFile("123", v3api.CallParams{})
+	assert.Nil(file)
+	assert.Equal("404: not_found_error-Not found", err.Error())
+}
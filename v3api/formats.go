@@ -0,0 +1,128 @@
+package v3api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/nytimes/threeplay/types"
+)
+
+// ErrUnsupportedFormat is matched by UnsupportedFormatError with errors.Is
+var ErrUnsupportedFormat = errors.New("unsupported output format")
+
+// UnsupportedFormatError is returned when a caption format has no output
+// format ID, neither built in nor among the account's custom formats
+type UnsupportedFormatError struct {
+	Format   types.CaptionsFormat
+	FormatID int
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Format == "" {
+		return fmt.Sprintf("%v: invalid output format id %d", ErrUnsupportedFormat, e.FormatID)
+	}
+	return fmt.Sprintf("%v: %q", ErrUnsupportedFormat, e.Format)
+}
+
+// Is reports whether target is ErrUnsupportedFormat
+func (e *UnsupportedFormatError) Is(target error) bool {
+	return target == ErrUnsupportedFormat
+}
+
+// OutputFormat is an output format available to an account, including the
+// custom formats set up for it
+type OutputFormat struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Extension string `json:"extension"`
+	Custom    bool   `json:"custom"`
+}
+
+// ThreePlayOutputFormatsResponse is the response of the output formats listing
+type ThreePlayOutputFormatsResponse struct {
+	Code  int            `json:"code"`
+	Data  []OutputFormat `json:"data"`
+	Error ThreePlayError `json:"error"`
+}
+
+// matches reports whether the output format is the custom format named format
+func (f OutputFormat) matches(format types.CaptionsFormat) bool {
+	return f.Custom && strings.EqualFold(f.Name, string(format))
+}
+
+// formatCache keeps the output format catalog of each API key
+type formatCache struct {
+	mu    sync.Mutex
+	byKey map[string][]OutputFormat
+}
+
+func (f *formatCache) get(apiKey string) ([]OutputFormat, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	formats, ok := f.byKey[apiKey]
+	return formats, ok
+}
+
+func (f *formatCache) set(apiKey string, formats []OutputFormat) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.byKey == nil {
+		f.byKey = map[string][]OutputFormat{}
+	}
+	f.byKey[apiKey] = formats
+}
+
+// OutputFormats returns the output formats available to the account. The
+// list is fetched once per API key and cached for the life of the client.
+func (c *Client) OutputFormats(ctx context.Context, callParams CallParams) ([]OutputFormat, error) {
+	if formats, ok := c.formats.get(c.setAPIKey(callParams.APIKey)); ok {
+		return formats, nil
+	}
+	return c.RefreshOutputFormats(ctx, callParams)
+}
+
+// RefreshOutputFormats fetches the output formats available to the account,
+// replacing the cached list
+func (c *Client) RefreshOutputFormats(ctx context.Context, callParams CallParams) ([]OutputFormat, error) {
+	apiKey := c.setAPIKey(callParams.APIKey)
+	endpoint, err := c.createURL("/output_formats", url.Values{"api_key": {apiKey}})
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.httpClient.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	response := &ThreePlayOutputFormatsResponse{}
+	if err := c.parseResponse(res, response); err != nil {
+		return nil, err
+	}
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return nil, err
+	}
+	c.formats.set(apiKey, response.Data)
+	return response.Data, nil
+}
+
+// OutputFormatID returns the output format ID of a caption format. Formats
+// missing from TranscriptFormatToID are looked up by name among the account's
+// custom formats, e.g. types.CaptionsFormat("Newsroom SRT").
+func (c *Client) OutputFormatID(ctx context.Context, format types.CaptionsFormat, callParams CallParams) (int, error) {
+	if id, ok := TranscriptFormatToID[format]; ok {
+		return id, nil
+	}
+	formats, err := c.OutputFormats(ctx, callParams)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range formats {
+		if f.matches(format) {
+			return f.ID, nil
+		}
+	}
+	return 0, &UnsupportedFormatError{Format: format}
+}
@@ -0,0 +1,119 @@
+package v3api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func mockOutputFormats(times int) {
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/output_formats").
+		MatchParam("api_key", "api-key").
+		Times(times).
+		Reply(200).
+		JSON(map[string]interface{}{
+			"code": 200,
+			"data": []map[string]interface{}{
+				{"id": 7, "name": "SRT", "extension": "srt"},
+				{"id": 41, "name": "DFXP", "extension": "dfxp"},
+				{"id": 9001, "name": "Newsroom SRT", "extension": "srt", "custom": true},
+			},
+		})
+}
+
+func TestOutputFormatsAreCached(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+	mockOutputFormats(1)
+	client := v3api.NewClient("api-key")
+
+	for i := 0; i < 2; i++ {
+		formats, err := client.OutputFormats(context.Background(), v3api.CallParams{})
+		assert.Nil(err)
+		assert.Len(formats, 3)
+		assert.Equal(v3api.OutputFormat{ID: 9001, Name: "Newsroom SRT", Extension: "srt", Custom: true}, formats[2])
+	}
+	assert.True(gock.IsDone())
+
+	mockOutputFormats(1)
+	_, err := client.RefreshOutputFormats(context.Background(), v3api.CallParams{})
+	assert.Nil(err)
+	assert.True(gock.IsDone())
+}
+
+func TestTranscriptTextResolvesCustomFormat(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+	mockOutputFormats(1)
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/3633088/text").
+		MatchParam("output_format_id", "9001").
+		Reply(200).
+		File("../fixtures/v3_transcript_text.json")
+	client := v3api.NewClient("api-key")
+
+	transcript, err := client.GetTranscriptText("3633088", "", types.CaptionsFormat("newsroom srt"), v3api.CallParams{})
+	assert.Nil(err)
+	assert.NotEmpty(transcript)
+	assert.True(gock.IsDone())
+}
+
+func TestBuiltInFormatsSkipCatalog(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+	gock.Intercept()
+	client := v3api.NewClient("api-key")
+
+	formats := []types.CaptionsFormat{
+		types.SRT, types.WebVTT, types.DFX, types.SMI, types.STL,
+		types.QT, types.QTXML, types.CPTXML, types.ADBE,
+	}
+	for _, format := range formats {
+		id, err := client.OutputFormatID(context.Background(), format, v3api.CallParams{})
+		assert.Nil(err, format)
+		assert.Equal(v3api.TranscriptFormatToID[format], id, format)
+		assert.NotZero(id, format)
+	}
+}
+
+func TestTranscriptTextUnsupportedFormat(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+	mockOutputFormats(1)
+	client := v3api.NewClient("api-key")
+
+	// built-in formats of the catalog don't match by name
+	transcript, err := client.GetTranscriptText("3633088", "", types.CaptionsFormat("dfxp"), v3api.CallParams{})
+	assert.Empty(transcript)
+	assert.True(errors.Is(err, v3api.ErrUnsupportedFormat))
+	var formatErr *v3api.UnsupportedFormatError
+	assert.True(errors.As(err, &formatErr))
+	assert.Equal(types.CaptionsFormat("dfxp"), formatErr.Format)
+	assert.EqualError(err, `unsupported output format: "dfxp"`)
+
+	_, err = client.GetTranscriptTextByFormatID(context.Background(), "3633088", "", 0, v3api.CallParams{})
+	assert.EqualError(err, "unsupported output format: invalid output format id 0")
+}
+
+func TestOutputFormatIDOnFakeServer(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	id, err := client.OutputFormatID(context.Background(), types.SRT, v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal(7, id)
+	_, err = client.OutputFormatID(context.Background(), types.CaptionsFormat("docx"), v3api.CallParams{})
+	assert.True(errors.Is(err, v3api.ErrUnsupportedFormat))
+	formats, err := client.OutputFormats(context.Background(), v3api.CallParams{})
+	assert.Nil(err)
+	assert.Len(formats, 2)
+}
@@ -0,0 +1,36 @@
+package v3api
+
+import "github.com/nytimes/threeplay/internal/transport"
+
+// Event describes one attempt of a call, as passed to Hooks. Credentials are
+// redacted from URL and Err.
+type Event = transport.Event
+
+// Hooks observe the attempts of the calls made by a Client: OnRequest before
+// each, then OnResponse or OnError, and OnRetry before waiting for the next
+// one. They are called synchronously and should return quickly.
+type Hooks = transport.Hooks
+
+// APIErrorHooks are Hooks also told, through OnAPIError, about the errors
+// 3Play reports in response payloads, with ErrorType set to the Type of the
+// APIError returned.
+type APIErrorHooks = transport.APIErrorHooks
+
+// WithHooks adds hooks observing the calls made by the client. It can be
+// given more than once.
+func WithHooks(hooks Hooks) Option {
+	return func(c *Client) {
+		c.httpClient.Hooks = append(c.httpClient.Hooks, hooks)
+	}
+}
+
+// Logger is the structured logger WithLogger writes to. *slog.Logger
+// satisfies it.
+type Logger = transport.Logger
+
+// WithLogger logs the calls made by the client: requests and responses at
+// debug level, failed attempts, throttling, server errors, retries and the
+// errors reported by 3Play at warn level.
+func WithLogger(logger Logger) Option {
+	return WithHooks(transport.LogHooks(logger))
+}
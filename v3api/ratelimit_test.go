@@ -0,0 +1,37 @@
+package v3api_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/ratelimit"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	id := strconv.Itoa(server.AddFile("video.mp4"))
+
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 0.001, Burst: 1})
+	client := v3api.NewClient(server.APIKey, append(server.V3Options(), v3api.WithRateLimiter(limiter))...)
+	other := v3api.CallParams{APIKey: "other-key"}
+
+	_, err := client.GetFileContext(context.Background(), id, v3api.CallParams{})
+	assert.Nil(err)
+	// every API key has its own budget
+	_, err = client.GetFileContext(context.Background(), id, other)
+	assert.NotEqual(context.DeadlineExceeded, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.GetFileContext(ctx, id, v3api.CallParams{})
+	assert.Equal(context.DeadlineExceeded, err)
+	_, err = client.GetFileContext(ctx, id, other)
+	assert.Equal(context.DeadlineExceeded, err)
+}
@@ -0,0 +1,158 @@
+package v3api_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+var noBackoff = v3api.WithRetryPolicy(v3api.RetryPolicy{
+	Backoff: func(int) time.Duration { return 0 },
+})
+
+func TestOrderTranscriptReturnsExistingAfterServerError(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/transcripts/order/asr").
+		Times(1).
+		Reply(502).
+		File("../fixtures/v3_unknown_error.json")
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/3628667").
+		MatchParam("api_key", "api-key").
+		Reply(200).
+		File("../fixtures/v3_transcript_order_200.json")
+
+	client := v3api.NewClient("api-key", noBackoff)
+	transcript, err := client.OrderTranscript("3628667", "", "asr", v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal(10790718, transcript.ID)
+	assert.True(gock.IsDone())
+}
+
+func TestOrderTranscriptResendsWhenNotPlaced(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/transcripts/order/asr").
+		Reply(500).
+		File("../fixtures/v3_unknown_error.json")
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/3628667").
+		Reply(404).
+		File("../fixtures/v3_transcript_order_404.json")
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/transcripts/order/asr").
+		Reply(200).
+		File("../fixtures/v3_transcript_order_200.json")
+
+	client := v3api.NewClient("api-key", noBackoff)
+	transcript, err := client.OrderTranscript("3628667", "", "asr", v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal("pending", transcript.Status)
+	assert.True(gock.IsDone())
+}
+
+func TestOrderTranscriptGivesUpWhenUnsure(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Post("/v3/transcripts/order/asr").
+		Reply(500).
+		File("../fixtures/v3_unknown_error.json")
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/3628667").
+		Reply(401).
+		JSON(map[string]interface{}{"code": 401, "error": map[string]string{"type": "authentication_error", "message": "Invalid API key"}})
+
+	client := v3api.NewClient("api-key", v3api.WithRetryPolicy(v3api.RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     func(int) time.Duration { return 0 },
+	}))
+	_, err := client.OrderTranscript("3628667", "", "asr", v3api.CallParams{})
+	assert.NotNil(err)
+	assert.True(gock.IsDone())
+}
+
+func TestOrderTranscriptIgnoresEarlierTranscripts(t *testing.T) {
+	tests := []struct {
+		name            string
+		turnaroundLevel string
+		endpoint        string
+		info            string
+	}{
+		{"complete", "asr", "/v3/transcripts/order/asr", "../fixtures/v3_transcript_info_complete.json"},
+		{"other type", "3", "/v3/transcripts/order/transcription", "../fixtures/v3_transcript_order_200.json"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			defer gock.Off()
+
+			gock.New("https://api.3playmedia.com").
+				Post(test.endpoint).
+				Times(1).
+				Reply(502).
+				File("../fixtures/v3_unknown_error.json")
+			gock.New("https://api.3playmedia.com").
+				Get("/v3/transcripts/3628667").
+				Reply(200).
+				File(test.info)
+
+			client := v3api.NewClient("api-key", noBackoff)
+			_, err := client.OrderTranscript("3628667", "", test.turnaroundLevel, v3api.CallParams{})
+			var apiErr *v3api.APIError
+			assert.True(errors.As(err, &apiErr))
+			assert.Equal(http.StatusBadGateway, apiErr.StatusCode)
+			assert.True(gock.IsDone())
+		})
+	}
+}
+
+type refusedTransport struct {
+	requests map[string]int
+}
+
+func (r *refusedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests[req.Method]++
+	return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+func TestOrderTranscriptAttemptsOncePerRetry(t *testing.T) {
+	assert := assert.New(t)
+	refused := &refusedTransport{requests: map[string]int{}}
+	client := v3api.NewClientWithHTTPClient("api-key", &http.Client{Transport: refused}, v3api.WithRetryPolicy(v3api.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return 0 },
+	}))
+
+	_, err := client.OrderTranscript("3628667", "", "asr", v3api.CallParams{})
+	assert.NotNil(err)
+	assert.Equal(map[string]int{http.MethodPost: 3}, refused.requests)
+}
+
+func TestWithRetryPolicyMaxAttempts(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/123").
+		Times(1).
+		Reply(500).
+		File("../fixtures/v3_unknown_error.json")
+
+	client := v3api.NewClient("api-key", v3api.WithRetryPolicy(v3api.RetryPolicy{MaxAttempts: 1}))
+	_, err := client.GetTranscriptInfo("123", v3api.CallParams{})
+	assert.NotNil(err)
+	assert.True(gock.IsDone())
+}
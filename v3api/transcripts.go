@@ -1,16 +1,32 @@
 package v3api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/nytimes/threeplay/captions"
+	"github.com/nytimes/threeplay/internal/transport"
 	"github.com/nytimes/threeplay/types"
 )
 
-// TranscriptFormatToID maps a caption format to their 3play code
+// TranscriptFormatToID maps a caption format to their 3play code. Formats
+// missing here are resolved from the account's custom output formats.
 var TranscriptFormatToID = map[types.CaptionsFormat]int{
 	types.WebVTT: 139,
 	types.SRT:    7,
+	types.DFX:    4,
+	types.SMI:    5,
+	types.QT:     6,
+	types.STL:    12,
+	types.QTXML:  30,
+	types.CPTXML: 44,
+	types.ADBE:   48,
 }
 
 // ThreePlayTranscriptResponse the info response object
@@ -36,8 +52,9 @@ type ThreePlayTranscriptCancelResponse struct {
 
 // ThreePlayError represents the content of a transcript error response
 type ThreePlayError struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
+	Type    string      `json:"type"`
+	Message string      `json:"message"`
+	Errors  FieldErrors `json:"errors"`
 }
 
 // TranscriptObjectRepresentation represents the content of a transcript info response
@@ -53,6 +70,12 @@ type TranscriptObjectRepresentation struct {
 	CancellationDetails string  `json:"cancellation_details"`
 }
 
+// Transcript types reported by the API
+const (
+	TranscriptTypeASR           = "AsrTranscript"
+	TranscriptTypeTranscription = "TranscriptionTranscript"
+)
+
 // CancelObjectRepresentation represents the content of a cancel response
 type CancelObjectRepresentation struct {
 	Success bool `json:"success"`
@@ -65,7 +88,17 @@ type CallParams struct {
 
 // OrderTranscript orders a transcript generation job
 func (c *Client) OrderTranscript(mediaFileID, callbackURL, turnaroundLevel string, callParams CallParams) (*TranscriptObjectRepresentation, error) {
-	var apiURL url.URL
+	return c.OrderTranscriptContext(context.Background(), mediaFileID, callbackURL, turnaroundLevel, callParams)
+}
+
+// OrderTranscriptContext is like OrderTranscript but carries ctx through the
+// request and its retries. An order may reach 3Play even though the call
+// fails, so before sending it again the media file is checked for a
+// transcript. A pending or in progress transcript of the type ordered is
+// returned instead of ordering twice; any other transcript leaves the order's
+// error to the caller.
+func (c *Client) OrderTranscriptContext(ctx context.Context, mediaFileID, callbackURL, turnaroundLevel string, callParams CallParams) (*TranscriptObjectRepresentation, error) {
+	var endpoint string
 	apiKey := c.setAPIKey(callParams.APIKey)
 	data := url.Values{}
 
@@ -75,106 +108,266 @@ func (c *Client) OrderTranscript(mediaFileID, callbackURL, turnaroundLevel strin
 		data.Set("callback", callbackURL)
 	}
 	if turnaroundLevel == "asr" {
-		apiURL = c.createURL("/transcripts/order/asr")
+		endpoint = "/transcripts/order/asr"
 	} else {
-		apiURL = c.createURL("/transcripts/order/transcription")
+		endpoint = "/transcripts/order/transcription"
 		data.Set("turnaround_level_id", turnaroundLevel)
 	}
-	res, err := c.httpClient.PostForm(apiURL.String(), data)
+	apiURL, err := c.createURL(endpoint, nil)
+	if err != nil {
+		return &TranscriptObjectRepresentation{}, err
+	}
+
+	// the order is retried here rather than by the transport, so that the
+	// media file is checked before every attempt after the first
+	once := *c.httpClient
+	once.Policy.MaxAttempts = 1
+	transcriptType := TranscriptTypeTranscription
+	if turnaroundLevel == "asr" {
+		transcriptType = TranscriptTypeASR
+	}
+	policy := c.httpClient.Policy
+	for attempt := 1; ; attempt++ {
+		transcript, err := c.order(ctx, &once, apiURL, data)
+		if err == nil || attempt >= policy.Attempts() {
+			return transcript, err
+		}
+		rejected := orderRejected(err)
+		if !rejected && !orderMayHaveSucceeded(ctx, err) {
+			return transcript, err
+		}
+		if err := policy.Wait(ctx, attempt); err != nil {
+			return &TranscriptObjectRepresentation{}, err
+		}
+		if rejected {
+			continue
+		}
+		existing, infoErr := c.GetTranscriptInfoContext(ctx, mediaFileID, callParams)
+		if infoErr == nil {
+			if placedBy(existing, transcriptType) {
+				return existing, nil
+			}
+			// an earlier transcript: this order may or may not have
+			// replaced it yet
+			return transcript, err
+		}
+		if !errors.Is(infoErr, ErrNotFound) {
+			// without knowing whether the order went through, sending it
+			// again could pay for a second transcript
+			return transcript, err
+		}
+	}
+}
+
+func (c *Client) order(ctx context.Context, httpClient *transport.Client, apiURL string, data url.Values) (*TranscriptObjectRepresentation, error) {
+	res, err := httpClient.PostForm(ctx, apiURL, data)
 	if err != nil {
 		return &TranscriptObjectRepresentation{}, err
 	}
 	response := &ThreePlayTranscriptResponse{}
-	if err := parseResponse(res, response); err != nil {
+	if err := c.parseResponse(res, response); err != nil {
 		return &TranscriptObjectRepresentation{}, err
 	}
-	if response.Code != 200 {
-		return &TranscriptObjectRepresentation{}, fmt.Errorf("%v: %v-%v", response.Code, response.Error.Type, response.Error.Message)
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return &TranscriptObjectRepresentation{}, err
 	}
 	return &response.Data, nil
 }
 
+// placedBy reports whether the transcript found after a failed order may be
+// the one it placed: a transcript of the type ordered that is still being
+// worked on. Finished, cancelled and failed transcripts predate the order.
+func placedBy(transcript *TranscriptObjectRepresentation, transcriptType string) bool {
+	if transcript.Type != transcriptType {
+		return false
+	}
+	switch transcript.Status {
+	case TranscriptStatusPending, TranscriptStatusInProgress:
+		return true
+	}
+	return false
+}
+
+// orderRejected reports whether the order surely wasn't placed and can be
+// sent again: the connection couldn't be established or 3Play asked for it
+// to be sent later
+func orderRejected(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return transport.NotSent(err)
+}
+
+// orderMayHaveSucceeded reports whether a failed order may still have been
+// placed: the connection broke or 3Play failed while handling it
+func orderMayHaveSucceeded(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
 // GetTranscriptInfo gets the status of the transcript job
 func (c *Client) GetTranscriptInfo(mediaFileID string, callParams CallParams) (*TranscriptObjectRepresentation, error) {
+	return c.GetTranscriptInfoContext(context.Background(), mediaFileID, callParams)
+}
+
+// GetTranscriptInfoContext is like GetTranscriptInfo but carries ctx through
+// the request and its retries.
+func (c *Client) GetTranscriptInfoContext(ctx context.Context, mediaFileID string, callParams CallParams) (*TranscriptObjectRepresentation, error) {
 	apiKey := c.setAPIKey(callParams.APIKey)
-	endpoint := fmt.Sprintf("https://%s/v3/transcripts/%s?api_key=%s",
-		types.ThreePlayHost, mediaFileID, apiKey,
-	)
+	endpoint, err := c.createURL(fmt.Sprintf("/transcripts/%s", mediaFileID), url.Values{
+		"api_key": {apiKey},
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	res, err := c.httpClient.Get(endpoint)
+	res, err := c.httpClient.Get(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
 	response := &ThreePlayTranscriptResponse{}
-	if err := parseResponse(res, response); err != nil {
+	if err := c.parseResponse(res, response); err != nil {
 		return &TranscriptObjectRepresentation{}, err
 	}
-	if response.Code != 200 {
-		return &TranscriptObjectRepresentation{}, fmt.Errorf("%v: %v-%v", response.Code, response.Error.Type, response.Error.Message)
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return &TranscriptObjectRepresentation{}, err
 	}
 	return &response.Data, nil
 }
 
 // GetTranscriptText downloads the transcript in the specified format
 func (c *Client) GetTranscriptText(mediaFileID, offset string, outputFormat types.CaptionsFormat, callParams CallParams) (string, error) {
-	apiKey := c.setAPIKey(callParams.APIKey)
-	endpoint := fmt.Sprintf("https://%s/v3/transcripts/%s/text?api_key=%s&output_format_id=%d",
-		types.ThreePlayHost, mediaFileID, apiKey, TranscriptFormatToID[outputFormat],
-	)
-	if offset != "" {
-		endpoint += fmt.Sprintf("&offset=%s", offset)
-	}
-	res, err := c.httpClient.Get(endpoint)
+	return c.GetTranscriptTextContext(context.Background(), mediaFileID, offset, outputFormat, callParams)
+}
+
+// GetTranscriptTextContext is like GetTranscriptText but carries ctx through
+// the request and its retries. Formats without a built-in ID are looked up
+// among the account's custom output formats, and an UnsupportedFormatError is
+// returned when there's none.
+func (c *Client) GetTranscriptTextContext(ctx context.Context, mediaFileID, offset string, outputFormat types.CaptionsFormat, callParams CallParams) (string, error) {
+	formatID, err := c.OutputFormatID(ctx, outputFormat, callParams)
 	if err != nil {
 		return "", err
 	}
+	return c.GetTranscriptTextByFormatID(ctx, mediaFileID, offset, formatID, callParams)
+}
 
-	response := &ThreePlayTranscriptTextResponse{}
-	if err := parseResponse(res, response); err != nil {
+// GetTranscriptTextByFormatID downloads the transcript in the output format
+// with the given ID, e.g. a custom format from OutputFormats
+func (c *Client) GetTranscriptTextByFormatID(ctx context.Context, mediaFileID, offset string, formatID int, callParams CallParams) (string, error) {
+	var buf strings.Builder
+	if _, err := c.GetTranscriptTextByFormatIDTo(ctx, &buf, mediaFileID, offset, formatID, callParams); err != nil {
 		return "", err
 	}
-	if response.Code != 200 {
-		return "", fmt.Errorf("%v: %v-%v", response.Code, response.Error.Type, response.Error.Message)
+	return buf.String(), nil
+}
+
+// GetTranscriptTextTo is like GetTranscriptTextContext but streams the
+// transcript to w instead of holding it in memory, and returns the number of
+// bytes written. Transcripts larger than the client's maximum download size
+// fail with ErrBodyTooLarge once that many bytes were written.
+func (c *Client) GetTranscriptTextTo(ctx context.Context, w io.Writer, mediaFileID, offset string, outputFormat types.CaptionsFormat, callParams CallParams) (int64, error) {
+	formatID, err := c.OutputFormatID(ctx, outputFormat, callParams)
+	if err != nil {
+		return 0, err
 	}
-	return response.Data, nil
+	return c.GetTranscriptTextByFormatIDTo(ctx, w, mediaFileID, offset, formatID, callParams)
+}
+
+// GetTranscriptTextByFormatIDTo is like GetTranscriptTextByFormatID but
+// streams the transcript to w like GetTranscriptTextTo
+func (c *Client) GetTranscriptTextByFormatIDTo(ctx context.Context, w io.Writer, mediaFileID, offset string, formatID int, callParams CallParams) (int64, error) {
+	if formatID <= 0 {
+		return 0, &UnsupportedFormatError{FormatID: formatID}
+	}
+	apiKey := c.setAPIKey(callParams.APIKey)
+	query := url.Values{}
+	query.Set("api_key", apiKey)
+	query.Set("output_format_id", strconv.Itoa(formatID))
+	if offset != "" {
+		query.Set("offset", offset)
+	}
+	endpoint, err := c.createURL(fmt.Sprintf("/transcripts/%s/text", mediaFileID), query)
+	if err != nil {
+		return 0, err
+	}
+	return c.downloadText(ctx, w, endpoint)
+}
+
+// GetCaptionsParsed downloads the transcript in one of the formats supported
+// by the captions package and parses it into cues
+func (c *Client) GetCaptionsParsed(ctx context.Context, mediaFileID string, format types.CaptionsFormat, callParams CallParams) ([]captions.Cue, error) {
+	text, err := c.GetTranscriptTextContext(ctx, mediaFileID, "", format, callParams)
+	if err != nil {
+		return nil, err
+	}
+	return captions.Parse(strings.NewReader(text), format)
 }
 
 // CancelTranscript cancels the transcript order if possible
 func (c *Client) CancelTranscript(mediaFileID string, callParams CallParams) error {
+	return c.CancelTranscriptContext(context.Background(), mediaFileID, callParams)
+}
+
+// CancelTranscriptContext is like CancelTranscript but carries ctx through the
+// request and its retries.
+func (c *Client) CancelTranscriptContext(ctx context.Context, mediaFileID string, callParams CallParams) error {
 	apiKey := c.setAPIKey(callParams.APIKey)
-	apiURL := c.createURL(fmt.Sprintf("/transcripts/%s/cancel", mediaFileID))
+	apiURL, err := c.createURL(fmt.Sprintf("/transcripts/%s/cancel", mediaFileID), nil)
+	if err != nil {
+		return err
+	}
 	data := url.Values{}
 	data.Set("api_key", apiKey)
-	res, err := c.httpClient.PostForm(apiURL.String(), data)
+	// cancelling twice has no further effect
+	res, err := c.httpClient.PostForm(transport.MarkIdempotent(ctx), apiURL, data)
 	if err != nil {
 		return err
 	}
 	response := &ThreePlayTranscriptCancelResponse{}
-	if err := parseResponse(res, response); err != nil {
+	if err := c.parseResponse(res, response); err != nil {
 		return err
 	}
-	if response.Code != 200 {
-		return fmt.Errorf("%v: %v-%v", response.Code, response.Error.Type, response.Error.Message)
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return err
 	}
 	return nil
 }
 
 // GetEditingLink gets an expiring editing link
 func (c *Client) GetEditingLink(mediaFileID string, hoursUntilExpiration int, callParams CallParams) (string, error) {
+	return c.GetEditingLinkContext(context.Background(), mediaFileID, hoursUntilExpiration, callParams)
+}
+
+// GetEditingLinkContext is like GetEditingLink but carries ctx through the
+// request and its retries.
+func (c *Client) GetEditingLinkContext(ctx context.Context, mediaFileID string, hoursUntilExpiration int, callParams CallParams) (string, error) {
 	apiKey := c.setAPIKey(callParams.APIKey)
-	endpoint := fmt.Sprintf("https://%s/v3/transcripts/%s/expiring_editing_link?api_key=%s&hours_until_expiration=%d",
-		types.ThreePlayHost, mediaFileID, apiKey, hoursUntilExpiration,
-	)
-	res, err := c.httpClient.Get(endpoint)
+	endpoint, err := c.createURL(fmt.Sprintf("/transcripts/%s/expiring_editing_link", mediaFileID), url.Values{
+		"api_key":                {apiKey},
+		"hours_until_expiration": {strconv.Itoa(hoursUntilExpiration)},
+	})
+	if err != nil {
+		return "", err
+	}
+	res, err := c.httpClient.Get(ctx, endpoint)
 	if err != nil {
 		return "", err
 	}
 	response := &ThreePlayTranscriptTextResponse{}
-	if err := parseResponse(res, response); err != nil {
-		return "", nil
+	if err := c.parseResponse(res, response); err != nil {
+		return "", err
 	}
-	if response.Code != 200 {
-		return "", fmt.Errorf("%v: %v-%v", response.Code, response.Error.Type, response.Error.Message)
+	if err := checkResponseCode(res, response.Code, response.Error); err != nil {
+		return "", err
 	}
 	return response.Data, nil
 }
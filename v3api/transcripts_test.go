@@ -1,8 +1,14 @@
 package v3api_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/types"
 	"github.com/nytimes/threeplay/v3api"
 	"github.com/stretchr/testify/assert"
 	gock "gopkg.in/h2non/gock.v1"
@@ -224,3 +230,57 @@ func TestTranscriptEditingLinkError(t *testing.T) {
 	assert.NotNil(err)
 	assert.Equal("404: not_found_error-Not found", err.Error())
 }
+
+func TestTranscriptInfoContextCancelled(t *testing.T) {
+	assert := assert.New(t)
+	defer gock.Off()
+
+	gock.New("https://api.3playmedia.com").
+		Get("/v3/transcripts/123").
+		MatchParam("api_key", "api-key").
+		Persist().
+		Reply(500).
+		File("../fixtures/v3_unknown_error.json")
+
+	client := v3api.NewClient("api-key")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	transcriptData, err := client.GetTranscriptInfoContext(ctx, "123", v3api.CallParams{})
+	assert.Nil(transcriptData)
+	assert.Equal(context.DeadlineExceeded, err)
+}
+
+func TestTranscriptInfoWithBaseURL(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v3/transcripts/3633088", r.URL.Path)
+		assert.Equal("api-key", r.URL.Query().Get("api_key"))
+		http.ServeFile(w, r, "../fixtures/v3_transcript_info_complete.json")
+	}))
+	defer server.Close()
+
+	client := v3api.NewClient("api-key", v3api.WithBaseURL(server.URL))
+	transcriptData, err := client.GetTranscriptInfo("3633088", v3api.CallParams{})
+	assert.Nil(err)
+	assert.Equal("complete", transcriptData.Status)
+}
+
+func TestGetCaptionsParsed(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	fileID, mediaFileID := orderOnFakeServer(t, server, client)
+	assert.Nil(server.CompleteTranscript(fileID))
+
+	srt, err := client.GetCaptionsParsed(context.Background(), mediaFileID, types.SRT, v3api.CallParams{})
+	assert.Nil(err)
+	vtt, err := client.GetCaptionsParsed(context.Background(), mediaFileID, types.WebVTT, v3api.CallParams{})
+	assert.Nil(err)
+	assert.NotEmpty(srt)
+	assert.Equal(len(srt), len(vtt))
+	assert.Equal(srt[0].Start, vtt[0].Start)
+	assert.Equal(srt[0].Lines, vtt[0].Lines)
+}
@@ -0,0 +1,179 @@
+package v3api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Transcript statuses reported by the API
+const (
+	TranscriptStatusPending    = "pending"
+	TranscriptStatusInProgress = "in_progress"
+	TranscriptStatusComplete   = "complete"
+	TranscriptStatusCancelled  = "cancelled"
+	TranscriptStatusFailed     = "failed"
+)
+
+// Default polling settings of WaitForTranscript
+const (
+	DefaultWaitInterval    = 30 * time.Second
+	DefaultWaitMaxInterval = 5 * time.Minute
+	DefaultWaitMultiplier  = 1.5
+)
+
+var (
+	// ErrWaitTimeout is reported when a transcript doesn't reach a terminal
+	// status within WaitOptions.MaxWait
+	ErrWaitTimeout = errors.New("timed out waiting for transcript")
+	// ErrTranscriptCancelled is reported when the transcript was cancelled
+	ErrTranscriptCancelled = errors.New("transcript cancelled")
+	// ErrTranscriptFailed is reported when the transcript failed
+	ErrTranscriptFailed = errors.New("transcript failed")
+)
+
+// WaitOptions configures WaitForTranscript. The zero value polls with the
+// default settings until ctx is done.
+type WaitOptions struct {
+	// Interval is the wait before the second poll. Defaults to
+	// DefaultWaitInterval.
+	Interval time.Duration
+	// Multiplier grows the interval after each poll. Values below 1 default
+	// to DefaultWaitMultiplier; use 1 for a constant interval.
+	Multiplier float64
+	// MaxInterval caps the interval. Defaults to DefaultWaitMaxInterval.
+	MaxInterval time.Duration
+	// MaxWait bounds the whole wait. Zero means no limit other than ctx.
+	MaxWait time.Duration
+	// OnStatusChange is called with the transcript every time its status
+	// differs from the previous poll, including the first one.
+	OnStatusChange func(*TranscriptObjectRepresentation)
+	// CallParams is passed to every GetTranscriptInfo call
+	CallParams CallParams
+}
+
+// WaitError is returned by WaitForTranscript when the transcript doesn't
+// complete. It wraps ErrWaitTimeout, ErrTranscriptCancelled,
+// ErrTranscriptFailed or the ctx error, so it can be checked with errors.Is.
+type WaitError struct {
+	MediaFileID string
+	// Status is the last status seen, empty if the first poll didn't return
+	Status string
+	// CancellationReason and CancellationDetails explain why 3Play cancelled
+	// the transcript, when it did
+	CancellationReason  string
+	CancellationDetails string
+	Err                 error
+}
+
+func (e *WaitError) Error() string {
+	msg := fmt.Sprintf("waiting for transcript %s: %v", e.MediaFileID, e.Err)
+	if e.Status != "" {
+		msg += fmt.Sprintf(" (status %s)", e.Status)
+	}
+	if e.CancellationReason != "" {
+		msg += fmt.Sprintf(": %s", e.CancellationReason)
+	}
+	if e.CancellationDetails != "" {
+		msg += fmt.Sprintf(" - %s", e.CancellationDetails)
+	}
+	return msg
+}
+
+// Unwrap returns the reason the wait ended
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// IsTerminalStatus reports whether a transcript status won't change anymore
+func IsTerminalStatus(status string) bool {
+	switch status {
+	case TranscriptStatusComplete, TranscriptStatusCancelled, TranscriptStatusFailed:
+		return true
+	}
+	return false
+}
+
+// WaitForTranscript polls GetTranscriptInfo until the transcript of the media
+// file is complete, cancelled or failed. It returns the transcript when it
+// completes. Otherwise it returns the last transcript seen, if any, along with
+// a *WaitError. API errors are returned as they are.
+func (c *Client) WaitForTranscript(ctx context.Context, mediaFileID string, opts WaitOptions) (*TranscriptObjectRepresentation, error) {
+	opts = opts.withDefaults()
+	waitCtx, cancel := ctx, context.CancelFunc(func() {})
+	if opts.MaxWait > 0 {
+		waitCtx, cancel = context.WithTimeout(ctx, opts.MaxWait)
+	}
+	defer cancel()
+
+	var (
+		last     *TranscriptObjectRepresentation
+		interval = opts.Interval
+	)
+	waitErr := func(err error) *WaitError {
+		e := &WaitError{MediaFileID: mediaFileID, Err: err}
+		if ctx.Err() == nil && waitCtx.Err() != nil {
+			e.Err = ErrWaitTimeout
+		}
+		if last != nil {
+			e.Status = last.Status
+			e.CancellationReason = last.CancellationReason
+			e.CancellationDetails = last.CancellationDetails
+		}
+		return e
+	}
+
+	for {
+		transcript, err := c.GetTranscriptInfoContext(waitCtx, mediaFileID, opts.CallParams)
+		if err != nil {
+			if waitCtx.Err() != nil {
+				return last, waitErr(waitCtx.Err())
+			}
+			return last, err
+		}
+		if last == nil || last.Status != transcript.Status {
+			if opts.OnStatusChange != nil {
+				opts.OnStatusChange(transcript)
+			}
+		}
+		last = transcript
+
+		switch transcript.Status {
+		case TranscriptStatusComplete:
+			return transcript, nil
+		case TranscriptStatusCancelled:
+			return transcript, waitErr(ErrTranscriptCancelled)
+		case TranscriptStatusFailed:
+			return transcript, waitErr(ErrTranscriptFailed)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-waitCtx.Done():
+			timer.Stop()
+			return last, waitErr(waitCtx.Err())
+		case <-timer.C:
+		}
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.Interval <= 0 {
+		o.Interval = DefaultWaitInterval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = DefaultWaitMultiplier
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = DefaultWaitMaxInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	return o
+}
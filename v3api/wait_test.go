@@ -0,0 +1,113 @@
+package v3api_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+func orderOnFakeServer(t *testing.T, server *threeplaytest.Server, client *v3api.Client) (int, string) {
+	fileID := server.AddFile("speech.mp4")
+	mediaFileID := strconv.Itoa(fileID)
+	if _, err := client.OrderTranscript(mediaFileID, "", "asr", v3api.CallParams{}); err != nil {
+		t.Fatal(err)
+	}
+	return fileID, mediaFileID
+}
+
+func TestWaitForTranscript(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	_, mediaFileID := orderOnFakeServer(t, server, client)
+
+	var statuses []string
+	transcript, err := client.WaitForTranscript(context.Background(), mediaFileID, v3api.WaitOptions{
+		Interval: time.Millisecond,
+		OnStatusChange: func(transcript *v3api.TranscriptObjectRepresentation) {
+			statuses = append(statuses, transcript.Status)
+			server.Advance(threeplaytest.DefaultTurnaround)
+		},
+	})
+	assert.Nil(err)
+	assert.Equal(v3api.TranscriptStatusComplete, transcript.Status)
+	assert.Equal([]string{"pending", "complete"}, statuses)
+}
+
+func TestWaitForTranscriptCancelledBy3Play(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	fileID, mediaFileID := orderOnFakeServer(t, server, client)
+
+	transcript, err := client.WaitForTranscript(context.Background(), mediaFileID, v3api.WaitOptions{
+		Interval: time.Millisecond,
+		OnStatusChange: func(*v3api.TranscriptObjectRepresentation) {
+			server.RejectTranscript(fileID, "media_error", "No audio track")
+		},
+	})
+	assert.Equal(v3api.TranscriptStatusCancelled, transcript.Status)
+	assert.True(errors.Is(err, v3api.ErrTranscriptCancelled))
+
+	var waitErr *v3api.WaitError
+	assert.True(errors.As(err, &waitErr))
+	assert.Equal("media_error", waitErr.CancellationReason)
+	assert.Equal("No audio track", waitErr.CancellationDetails)
+	assert.Contains(err.Error(), "media_error - No audio track")
+}
+
+func TestWaitForTranscriptMaxWait(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	_, mediaFileID := orderOnFakeServer(t, server, client)
+
+	transcript, err := client.WaitForTranscript(context.Background(), mediaFileID, v3api.WaitOptions{
+		Interval:   5 * time.Millisecond,
+		Multiplier: 1,
+		MaxWait:    30 * time.Millisecond,
+	})
+	assert.Equal(v3api.TranscriptStatusPending, transcript.Status)
+	assert.True(errors.Is(err, v3api.ErrWaitTimeout))
+
+	var waitErr *v3api.WaitError
+	assert.True(errors.As(err, &waitErr))
+	assert.Equal(v3api.TranscriptStatusPending, waitErr.Status)
+}
+
+func TestWaitForTranscriptContextCancelled(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+	_, mediaFileID := orderOnFakeServer(t, server, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err := client.WaitForTranscript(ctx, mediaFileID, v3api.WaitOptions{
+		Interval: time.Hour,
+		MaxWait:  2 * time.Hour,
+	})
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+	assert.False(errors.Is(err, v3api.ErrWaitTimeout))
+}
+
+func TestWaitForTranscriptAPIError(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	client := v3api.NewClient(server.APIKey, server.V3Options()...)
+
+	transcript, err := client.WaitForTranscript(context.Background(), "404", v3api.WaitOptions{})
+	assert.Nil(transcript)
+	assert.True(errors.Is(err, v3api.ErrNotFound))
+}
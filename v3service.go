@@ -0,0 +1,114 @@
+package threeplay
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nytimes/threeplay/types"
+	"github.com/nytimes/threeplay/v3api"
+)
+
+// V3Service implements Service with the v3 API. v3 has no tags: Tags, AddTag
+// and RemoveTag return ErrNotSupported.
+type V3Service struct {
+	client *v3api.Client
+}
+
+var _ Service = (*V3Service)(nil)
+
+// NewV3Service returns a Service calling 3Play with client
+func NewV3Service(client *v3api.Client) *V3Service {
+	return &V3Service{client: client}
+}
+
+// Upload implements Service
+func (s *V3Service) Upload(ctx context.Context, req UploadRequest) (string, error) {
+	options := url.Values{"source_url": {req.SourceURL}}
+	if req.Name != "" {
+		options.Set("name", req.Name)
+	}
+	if req.ReferenceID != "" {
+		options.Set("reference_id", req.ReferenceID)
+	}
+	id, err := s.client.UploadFileFromURLContext(ctx, options, v3api.CallParams{})
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(id), nil
+}
+
+// Order implements Service
+func (s *V3Service) Order(ctx context.Context, fileID string, opts OrderOptions) (*Transcript, error) {
+	turnaround := opts.Turnaround
+	if turnaround == "" {
+		turnaround = TurnaroundASR
+	}
+	transcript, err := s.client.OrderTranscriptContext(ctx, fileID, opts.CallbackURL, turnaround, v3api.CallParams{})
+	if err != nil {
+		return nil, err
+	}
+	return v3Transcript(fileID, transcript), nil
+}
+
+// Status implements Service
+func (s *V3Service) Status(ctx context.Context, fileID string) (*Transcript, error) {
+	transcript, err := s.client.GetTranscriptInfoContext(ctx, fileID, v3api.CallParams{})
+	if err != nil {
+		return nil, err
+	}
+	return v3Transcript(fileID, transcript), nil
+}
+
+// Captions implements Service
+func (s *V3Service) Captions(ctx context.Context, fileID string, format types.CaptionsFormat) ([]byte, error) {
+	text, err := s.client.GetTranscriptTextContext(ctx, fileID, "", format, v3api.CallParams{})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+// TranscriptText implements Service. v3 serves transcripts in caption
+// formats only, the text is that of the captions with one cue per line.
+func (s *V3Service) TranscriptText(ctx context.Context, fileID string) (string, error) {
+	cues, err := s.client.GetCaptionsParsed(ctx, fileID, types.WebVTT, v3api.CallParams{})
+	if err != nil {
+		return "", err
+	}
+	lines := make([]string, len(cues))
+	for i, cue := range cues {
+		lines[i] = cue.Text()
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Cancel implements Service
+func (s *V3Service) Cancel(ctx context.Context, fileID string) error {
+	return s.client.CancelTranscriptContext(ctx, fileID, v3api.CallParams{})
+}
+
+// Tags implements Service. It always returns ErrNotSupported.
+func (s *V3Service) Tags(ctx context.Context, fileID string) ([]string, error) {
+	return nil, fmt.Errorf("%w: v3 tags", ErrNotSupported)
+}
+
+// AddTag implements Service. It always returns ErrNotSupported.
+func (s *V3Service) AddTag(ctx context.Context, fileID, tag string) ([]string, error) {
+	return nil, fmt.Errorf("%w: v3 tags", ErrNotSupported)
+}
+
+// RemoveTag implements Service. It always returns ErrNotSupported.
+func (s *V3Service) RemoveTag(ctx context.Context, fileID, tag string) ([]string, error) {
+	return nil, fmt.Errorf("%w: v3 tags", ErrNotSupported)
+}
+
+func v3Transcript(fileID string, t *v3api.TranscriptObjectRepresentation) *Transcript {
+	return &Transcript{
+		FileID:      fileID,
+		Status:      Status(t.Status),
+		Cancellable: t.Cancellable,
+	}
+}
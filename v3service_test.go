@@ -0,0 +1,49 @@
+package threeplay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nytimes/threeplay"
+	"github.com/nytimes/threeplay/threeplaytest"
+	"github.com/nytimes/threeplay/v3api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestV3Service(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	svc := threeplay.NewV3Service(v3api.NewClient(server.APIKey, server.V3Options()...))
+	ctx := context.Background()
+
+	exerciseService(t, server, svc)
+
+	_, err := svc.Tags(ctx, "1")
+	assert.True(errors.Is(err, threeplay.ErrNotSupported))
+	_, err = svc.AddTag(ctx, "1", "news")
+	assert.True(errors.Is(err, threeplay.ErrNotSupported))
+	_, err = svc.RemoveTag(ctx, "1", "news")
+	assert.True(errors.Is(err, threeplay.ErrNotSupported))
+}
+
+func TestV3ServiceCancel(t *testing.T) {
+	assert := assert.New(t)
+	server := threeplaytest.NewServer()
+	defer server.Close()
+	svc := threeplay.NewV3Service(v3api.NewClient(server.APIKey, server.V3Options()...))
+	ctx := context.Background()
+
+	fileID, err := svc.Upload(ctx, threeplay.UploadRequest{SourceURL: "https://example.com/speech.mp4"})
+	assert.Nil(err)
+	ordered, err := svc.Order(ctx, fileID, threeplay.OrderOptions{Turnaround: threeplay.TurnaroundASR})
+	assert.Nil(err)
+	assert.True(ordered.Cancellable)
+
+	assert.Nil(svc.Cancel(ctx, fileID))
+	status, err := svc.Status(ctx, fileID)
+	assert.Nil(err)
+	assert.Equal(threeplay.StatusCancelled, status.Status)
+	assert.False(status.Cancellable)
+}